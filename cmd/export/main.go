@@ -0,0 +1,87 @@
+// export выгружает данные бота из базы SQLite в JSONL или CSV за период, например,
+// чтобы архивировать историю перед удалением по сроку хранения или загрузить её в BI:
+//
+//	go run ./cmd/export -table events -format csv -since 2026-09-01T00:00:00Z -until 2026-10-01T00:00:00Z -o events.csv
+//
+// Выгрузку можно запускать при работающем боте.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/sqlite"
+)
+
+func main() {
+	dbPath := flag.String("db", envOr("SQLITE_PATH", "./data/tgbot.db"), "путь к базе (storage.sqlite_path)")
+	table := flag.String("table", "events", "таблица: "+strings.Join(sqlite.ExportTables(), ", "))
+	format := flag.String("format", sqlite.ExportJSONL, "формат: "+sqlite.ExportJSONL+" или "+sqlite.ExportCSV)
+	since := flag.String("since", "", "начало периода включительно (RFC 3339)")
+	until := flag.String("until", "", "конец периода не включительно (RFC 3339)")
+	output := flag.String("o", "-", "файл выгрузки, - для stdout")
+	flag.Parse()
+
+	sinceTime, err := parseTime(*since)
+	if err != nil {
+		fail("invalid -since: %v", err)
+	}
+	untilTime, err := parseTime(*until)
+	if err != nil {
+		fail("invalid -until: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// База открывается только для чтения: не создаётся по опечатке в пути и не мигрирует,
+	// поэтому выгрузка не меняет базу работающего бота или резервную копию
+	db, err := sqlite.OpenReadOnly(ctx, *dbPath)
+	if err != nil {
+		fail("%v", err)
+	}
+	defer db.Close()
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fail("%v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := sqlite.Export(ctx, db, *table, *format, sinceTime, untilTime, w)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ %s: выгружено записей: %d\n", *table, n)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "❌ "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,33 @@
+// repocheck проверяет repositories.json без запуска бота (удобно в CI и перед деплоем):
+//
+//	go run ./cmd/repocheck config/repositories.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sensetion/tgGitlabBot/pkg/config"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [path/to/repositories.json]\n", os.Args[0])
+	}
+	flag.Parse()
+
+	path := "config/repositories.json"
+	if flag.NArg() > 0 {
+		path = flag.Arg(0)
+	}
+
+	repositories, err := config.LoadRepositoriesFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s\n%v\n", path, err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stdout, "✅ %s: %d repositories, default route: %t\n",
+		path, len(repositories.Repositories), repositories.DefaultRoute != nil)
+}
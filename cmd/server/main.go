@@ -2,64 +2,307 @@ package main
 
 import (
 	"context"
-	"errors"
+	"database/sql"
+	"flag"
+	"fmt"
 	"log"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
+	"time"
+	_ "time/tzdata" // Встраиваем базу часовых поясов для тихих часов в минимальных образах
 
+	"github.com/sensetion/tgGitlabBot/internal/adapter/filestore"
+	"github.com/sensetion/tgGitlabBot/internal/adapter/sqlite"
+	"github.com/sensetion/tgGitlabBot/internal/adapter/telegram"
 	chihttp "github.com/sensetion/tgGitlabBot/internal/controller/http"
+	chimw "github.com/sensetion/tgGitlabBot/internal/controller/http/middleware"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
 	"github.com/sensetion/tgGitlabBot/pkg/config"
-	"github.com/sensetion/tgGitlabBot/pkg/logger"
 )
 
 func main() {
-	cfg, err := config.Load()
+	var opts config.Options
+	opts.RegisterFlags(flag.CommandLine)
+	printConfig := flag.Bool("print-config", false, "вывести итоговую конфигурацию (секреты скрыты) и выйти")
+	flag.Parse()
+
+	cfg, err := config.Load(opts)
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	//TODO: delete in release
-	logger.PrettyStructurePrint("📋 Loaded configuration:", cfg)
+	if *printConfig {
+		dump, err := cfg.Dump()
+		if err != nil {
+			log.Fatalf("failed to print config: %v", err)
+		}
+		_, _ = os.Stdout.Write(dump)
+		return
+	}
+
+	// Контекст фоновых задач, отменяется при завершении работы
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Секреты из файлов (<KEY>_FILE) перечитываются для ротации без перезапуска
+	botToken := config.NewRotatingSecret("telegram.bot_token", cfg.Telegram.BotToken, cfg.SecretFiles["telegram.bot_token"])
+	go botToken.Watch(ctx, cfg.Secrets.ReloadInterval)
+
+	instances := make([]chimw.GitLabInstance, 0, len(cfg.GitLab.Instances)+1)
+	for _, instance := range cfg.GitLab.AllInstances() {
+		secret := config.NewRotatingSecret(instance.SecretKey, instance.WebhookSecret, cfg.SecretFiles[instance.SecretKey])
+		go secret.Watch(ctx, cfg.Secrets.ReloadInterval)
+
+		instances = append(instances, chimw.GitLabInstance{Name: instance.Name, BaseURL: instance.BaseURL, Secret: secret.Value})
+		log.Printf("🦊 Инстанс GitLab %s %s", instance.Name, instance.BaseURL)
+	}
+
+	var db *sql.DB
+	if cfg.UsesDatabase() {
+		db, err = sqlite.Open(ctx, cfg.Storage.SQLitePath)
+		if err != nil {
+			log.Fatalf("failed to open database: %v", err)
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				log.Printf("❌ Ошибка закрытия базы данных: %v", err)
+			}
+		}()
+		log.Printf("🗄️ База данных: %s", cfg.Storage.SQLitePath)
+	}
+
+	// Настройки чатов читаются из кэша при каждом уведомлении и отправке отложенных пачек
+	var chats *usecase.ChatSettings
+	if cfg.ChatSettings.Enabled {
+		chats, err = usecase.NewChatSettings(ctx, sqlite.NewChatSettings(db))
+		if err != nil {
+			log.Fatalf("failed to open chat settings: %v", err)
+		}
+	}
+
+	tgClient := telegram.NewClient(cfg.Telegram.APIURL, botToken.Value, cfg.Telegram.Timeout, cfg.Telegram.MaxRetries)
+
+	var sender usecase.MessageSender = tgClient
+
+	// Уведомления об одном merge request или пайплайне отправляются ответами на первое сообщение о нём
+	if cfg.Threads.Enabled {
+		threads := usecase.NewThreadSender(sender, sqlite.NewThreads(db), cfg.Threads.TTL)
+		go threads.Run(ctx, time.Hour)
+		sender = threads
+	}
+
+	if len(cfg.QuietHours.Chats) > 0 {
+		schedules, err := cfg.QuietHours.Schedules()
+		if err != nil {
+			log.Fatalf("failed to build quiet hours schedules: %v", err)
+		}
+
+		heldStore, err := filestore.NewHeldMessageStore(cfg.QuietHours.StoragePath)
+		if err != nil {
+			log.Fatalf("failed to open held messages store: %v", err)
+		}
+
+		// Отложенные пачки, которые не удалось отправить, попадают в недоставленные, как и обычные уведомления
+		var deadLetterStore usecase.DeadLetterStore
+		if cfg.DeadLetters.Enabled {
+			deadLetterStore = sqlite.NewDeadLetters(db)
+		}
+
+		quietHours := usecase.NewQuietHoursSender(sender, heldStore, schedules, chats, deadLetterStore)
+		go quietHours.Run(ctx, cfg.QuietHours.CheckInterval)
+		sender = quietHours
+	}
+
+	priority, err := newPriorityPolicy(cfg.Priorities)
+	if err != nil {
+		log.Fatalf("failed to build priority policy: %v", err)
+	}
+
+	// Уведомления, не доставленные после всех попыток, сохраняются для разбора и повторной отправки
+	if cfg.DeadLetters.Enabled {
+		sender = usecase.NewDeadLetterSender(sender, sqlite.NewDeadLetters(db))
+	}
+
+	queueStore, closeQueueStore, err := openQueueStore(ctx, cfg.Delivery)
+	if err != nil {
+		log.Fatalf("failed to open delivery queue: %v", err)
+	}
+	defer closeQueueStore()
+
+	queue := usecase.NewDeliveryQueue(sender, queueStore, cfg.Delivery.QueueSize)
+	go queue.Run(ctx, cfg.Delivery.Workers)
+
+	var deadLetters *usecase.DeadLetters
+	if cfg.DeadLetters.Enabled {
+		deadLetters, err = usecase.NewDeadLetters(ctx, sqlite.NewDeadLetters(db), queue)
+		if err != nil {
+			log.Fatalf("failed to open dead letters: %v", err)
+		}
+	}
+
+	registry, err := openRepositoryRegistry(ctx, cfg, db)
+	if err != nil {
+		log.Fatalf("failed to open repository registry: %v", err)
+	}
+
+	// Таблица маршрутизации заполняется из реестра при repositories.Load
+	routing, err := usecase.NewRoutingTable(nil, nil)
+	if err != nil {
+		log.Fatalf("failed to build routing table: %v", err)
+	}
+	unknownProjects := usecase.NewUnknownProjects()
+
+	var history *usecase.EventHistory
+	if cfg.History.Enabled {
+		history = usecase.NewEventHistory(sqlite.NewEvents(db))
+	}
+
+	notifier := usecase.NewNotifier(routing, priority, unknownProjects, history, chats, queue)
+
+	repositories := usecase.NewRepositoryService(registry, notifier, func(set domain.RepositorySet) error {
+		return validateRepositories(cfg, set)
+	})
+	if err := repositories.Load(ctx); err != nil {
+		log.Fatalf("failed to load repositories: %v", err)
+	}
+
+	// Перезагрузка repositories.json при изменении файла и по SIGHUP; реестр в базе изменяется через API
+	var watcher *config.RepositoriesWatcher
+	if cfg.Registry.Backend == config.RegistryFile {
+		watcher = config.NewRepositoriesWatcher(cfg.RepositoriesPath, func(r *config.RepositoriesConfig) error {
+			if err := cfg.GitLab.CheckRepositories(r); err != nil {
+				return err
+			}
+			return repositories.Apply(r.Set())
+		})
+		go func() {
+			if err := watcher.Run(ctx); err != nil {
+				log.Printf("❌ Отслеживание repositories.json остановлено: %v", err)
+			}
+		}()
+	}
+
+	// События сохраняются в outbox до подтверждения хука и доставляются после перезапуска
+	var outbox *usecase.OutboxDispatcher
+	if cfg.Outbox.Enabled {
+		outbox = usecase.NewOutboxDispatcher(sqlite.NewOutbox(db), notifier, cfg.Outbox.RetryInterval)
+		go outbox.Run(ctx)
+	}
+
+	// Данные в базе старше сроков хранения удаляются в фоне
+	if db != nil {
+		retention := usecase.NewRetention(retentionPolicies(cfg, db, deadLetters), func(ctx context.Context) error {
+			return sqlite.Compact(ctx, db, cfg.Retention.Vacuum)
+		})
+		go retention.Run(ctx, cfg.Retention.Interval)
+	}
+
+	var idempotency *usecase.Idempotency
+	if cfg.Idempotency.Enabled() {
+		idempotency = usecase.NewIdempotency(cfg.Idempotency.TTL, cfg.Idempotency.MaxEntries)
+	}
+
+	// Команды бота из Telegram: через long polling или вебхук /webhook/telegram
+	updates := cfg.Telegram.Updates
+	var commands, webhookCommands *usecase.CommandRouter
+	if updates.Mode != config.UpdatesOff {
+		commands = newCommandRouter(ctx, tgClient, chats)
+	}
+	if updates.Mode == config.UpdatesWebhook {
+		webhookCommands = commands
+	}
+
+	r := chihttp.Init(cfg, chihttp.Deps{
+		Notifier:        notifier,
+		UnknownProjects: unknownProjects,
+		Repositories:    repositories,
+		Idempotency:     idempotency,
+		Outbox:          outbox,
+		DeadLetters:     deadLetters,
+		Events:          history,
+		Chats:           chats,
+		Commands:        webhookCommands,
+		Instances:       instances,
+	})
+
+	// Получение обновлений останавливается вместе с сервером
+	pollerDone := make(chan struct{})
+	if updates.Mode == config.UpdatesPolling {
+		// Пока у бота зарегистрирован вебхук, getUpdates отвечает ошибкой
+		if err := tgClient.DeleteWebhook(ctx); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
 
-	r := chihttp.Init(cfg)
-	port := strconv.Itoa(cfg.Server.Port)
+		poller := usecase.NewUpdatePoller(tgClient, sqlite.NewBotState(db), commands, updates.PollTimeout)
+		go func() {
+			defer close(pollerDone)
+			poller.Run(ctx)
+		}()
+	} else {
+		close(pollerDone)
+	}
 
-	// Запускаем HTTP-сервер
-	server := &http.Server{
-		Addr:              net.JoinHostPort("localhost", port),
-		Handler:           r,
-		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout, // Защита от Slowloris атак - тип DDoS-атаки, при которой
-		// атакующий умышленно медленно отправляет HTTP-заголовки, удерживая соединения открытыми и истощая
-		// пул доступных соединений на сервере. ReadHeaderTimeout принудительно закрывает соединение,
-		// если клиент не успел отправить все заголовки за отведенное время.
+	server, err := chihttp.NewServer(cfg.Server, r)
+	if err != nil {
+		log.Fatalf("failed to start server: %v", err)
 	}
 
 	// Запускаем сервер в отдельной горутине
 	go func() {
-		log.Printf("🚀 HTTP-сервер запущен на порту %s\n", port)
-		err := server.ListenAndServe()
-
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := server.Serve(ctx); err != nil {
 			log.Printf("❌ Ошибка запуска сервера: %v\n", err)
 		}
 	}()
 
+	if updates.Mode == config.UpdatesWebhook && updates.Webhook.Register {
+		if err := tgClient.SetWebhook(ctx, updates.Webhook.URL, updates.Webhook.SecretToken.Value()); err != nil {
+			log.Printf("❌ %v", err)
+		} else {
+			log.Printf("🪝 Вебхук Telegram зарегистрирован: %s", updates.Webhook.URL)
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				log.Println("📨 Получен SIGHUP, перезагружаем repositories.json и TLS-сертификат")
+				if watcher != nil {
+					watcher.Reload()
+				}
+				server.ReloadCertificate()
+			}
+		}
+	}()
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("🛑 Завершение работы сервера...")
+	cancel()
 
 	// Создаем контекст с таймаутом для остановки сервера
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.Shutdown)
-	defer cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.Shutdown)
+	defer shutdownCancel()
+
+	// Обновления, пришедшие после удаления вебхука, Telegram хранит до следующего запуска
+	if updates.Mode == config.UpdatesWebhook && updates.Webhook.DeleteOnShutdown {
+		if err := tgClient.DeleteWebhook(shutdownCtx); err != nil {
+			log.Printf("❌ %v", err)
+		} else {
+			log.Println("🪝 Вебхук Telegram удалён")
+		}
+	}
 
-	if err := server.Shutdown(ctx); err != nil {
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Printf("❌ Graceful shutdown не удался: %v. Принудительное закрытие...\n", err)
 
 		// Принудительно закрываем соединения
@@ -69,4 +312,132 @@ func main() {
 	} else {
 		log.Println("✅ Сервер остановлен корректно")
 	}
+
+	select {
+	case <-pollerDone:
+	case <-shutdownCtx.Done():
+		log.Println("⚠️ Получение команд Telegram не остановилось за время shutdown_timeout")
+	}
+}
+
+// newCommandRouter создаёт маршрутизатор команд бота. Имя бота нужно, чтобы в группах
+// не отвечать на команды вида /cmd@other_bot; без него принимаются все команды.
+func newCommandRouter(ctx context.Context, tgClient *telegram.Client, chats *usecase.ChatSettings) *usecase.CommandRouter {
+	username, err := tgClient.GetMe(ctx)
+	if err != nil {
+		log.Printf("⚠️ Не удалось получить имя бота: %v", err)
+	}
+
+	router := usecase.NewCommandRouter(tgClient, username)
+	if chats != nil {
+		usecase.RegisterChatCommands(router, chats)
+	}
+	return router
+}
+
+// openRepositoryRegistry открывает хранилище конфигурации репозиториев. Пустой реестр в базе
+// при первом запуске заполняется из repositories.json, если файл найден.
+func openRepositoryRegistry(ctx context.Context, cfg *config.Config, db *sql.DB) (usecase.RepositoryRegistry, error) {
+	if cfg.Registry.Backend != config.RegistrySQLite {
+		return filestore.NewRepositoryFile(cfg.RepositoriesPath), nil
+	}
+
+	registry := sqlite.NewRepositoryRegistry(db)
+
+	set, err := registry.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(set.Repositories) == 0 && set.DefaultRoute == nil && (len(cfg.Repositories) > 0 || cfg.DefaultRoute != nil) {
+		if err := registry.Import(ctx, domain.RepositorySet{Repositories: cfg.Repositories, DefaultRoute: cfg.DefaultRoute}); err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", cfg.RepositoriesPath, err)
+		}
+		log.Printf("📥 Импортировано репозиториев из %s: %d", cfg.RepositoriesPath, len(cfg.Repositories))
+	}
+
+	return registry, nil
+}
+
+// openQueueStore открывает хранилище очереди доставки; close вызывается при завершении
+func openQueueStore(ctx context.Context, cfg config.DeliveryConfig) (usecase.QueueStore, func(), error) {
+	if cfg.Backend != config.QueueWAL {
+		return usecase.NewMemoryQueueStore(), func() {}, nil
+	}
+
+	opts := filestore.WALOptions{SegmentSize: cfg.WAL.SegmentSize, SyncInterval: cfg.WAL.SyncInterval}
+	switch cfg.WAL.Sync {
+	case config.SyncAlways:
+		opts.Sync = filestore.WALSyncAlways
+	case config.SyncInterval:
+		opts.Sync = filestore.WALSyncInterval
+	case config.SyncNever:
+		opts.Sync = filestore.WALSyncNever
+	}
+
+	wal, err := filestore.OpenWALQueue(cfg.WAL.Dir, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	go wal.Run(ctx)
+	log.Printf("📒 Очередь доставки в журнале %s (fsync: %s)", cfg.WAL.Dir, cfg.WAL.Sync)
+
+	return wal, func() {
+		if err := wal.Close(); err != nil {
+			log.Printf("❌ Ошибка закрытия журнала очереди доставки: %v", err)
+		}
+	}, nil
+}
+
+// retentionPolicies - сроки хранения данных в базе; таблицы очищаются, даже если запись
+// в них отключена, чтобы после отключения не оставалось старых данных
+func retentionPolicies(cfg *config.Config, db *sql.DB, deadLetters *usecase.DeadLetters) []usecase.RetentionPolicy {
+	expireDeadLetters := sqlite.NewDeadLetters(db).DeleteBefore
+	if deadLetters != nil {
+		// Через сервис, чтобы обновился счётчик недоставленных сообщений
+		expireDeadLetters = deadLetters.Expire
+	}
+
+	return []usecase.RetentionPolicy{
+		{Data: "events", MaxAge: cfg.Retention.Events, Delete: sqlite.NewEvents(db).DeleteBefore},
+		{Data: "outbox", MaxAge: cfg.Retention.Outbox, Delete: sqlite.NewOutbox(db).DeleteDone},
+		{Data: "dead_letters", MaxAge: cfg.Retention.DeadLetters, Delete: expireDeadLetters},
+		// Ветка устаревает через threads.ttl после последнего сообщения, то есть к моменту expires_at
+		{Data: "threads", MaxAge: cfg.Threads.TTL, Delete: func(ctx context.Context, before time.Time) (int, error) {
+			return sqlite.NewThreads(db).DeleteExpired(ctx, before.Add(cfg.Threads.TTL))
+		}},
+	}
+}
+
+// validateRepositories проверяет конфигурацию репозиториев целиком перед применением
+func validateRepositories(cfg *config.Config, set domain.RepositorySet) error {
+	repositories := config.RepositoriesConfig{Repositories: set.Repositories, DefaultRoute: set.DefaultRoute}
+
+	validate := repositories.Validate
+	if cfg.Registry.Backend == config.RegistrySQLite {
+		validate = repositories.ValidateEntries
+	}
+	if err := validate(); err != nil {
+		return err
+	}
+
+	return cfg.GitLab.CheckRepositories(&repositories)
+}
+
+// newPriorityPolicy собирает политику приоритетов из конфигурации
+func newPriorityPolicy(cfg config.PriorityConfig) (*usecase.PriorityPolicy, error) {
+	defaultPriority, err := cfg.DefaultPriority()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]usecase.PriorityRule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		priority, err := domain.ParsePriority(rule.Priority)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, usecase.PriorityRule{Condition: rule.Condition, Priority: priority})
+	}
+
+	return usecase.NewPriorityPolicy(rules, defaultPriority, cfg.Critical.Pin, cfg.Critical.Mention)
 }
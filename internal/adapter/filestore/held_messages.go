@@ -0,0 +1,149 @@
+package filestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// HeldMessageStore хранит отложенные на тихие часы сообщения в JSON-файле,
+// чтобы они пережили перезапуск бота
+type HeldMessageStore struct {
+	mu       sync.Mutex
+	path     string
+	messages map[string][]domain.HeldMessage // chat_id -> сообщения
+	lastID   int64
+}
+
+// NewHeldMessageStore открывает хранилище и загружает ранее сохранённые сообщения
+func NewHeldMessageStore(path string) (*HeldMessageStore, error) {
+	s := &HeldMessageStore{
+		path:     path,
+		messages: make(map[string][]domain.HeldMessage),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read held messages: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.messages); err != nil {
+		return nil, fmt.Errorf("failed to parse held messages %s: %w", path, err)
+	}
+
+	for _, messages := range s.messages {
+		for _, msg := range messages {
+			s.lastID = max(s.lastID, msg.ID)
+		}
+	}
+	// Сообщения, сохранённые до появления ID, получают его при загрузке
+	for _, messages := range s.messages {
+		for i := range messages {
+			if messages[i].ID == 0 {
+				s.lastID++
+				messages[i].ID = s.lastID
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// Hold сохраняет сообщение и присваивает ему ID
+func (s *HeldMessageStore) Hold(msg domain.HeldMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chatID := msg.Notification.ChatID
+	s.lastID++
+	msg.ID = s.lastID
+	s.messages[chatID] = append(s.messages[chatID], msg)
+
+	if err := s.flush(); err != nil {
+		s.messages[chatID] = s.messages[chatID][:len(s.messages[chatID])-1]
+		if len(s.messages[chatID]) == 0 {
+			delete(s.messages, chatID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Chats возвращает чаты, для которых есть отложенные сообщения
+func (s *HeldMessageStore) Chats() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := make([]string, 0, len(s.messages))
+	for chatID := range s.messages {
+		chats = append(chats, chatID)
+	}
+	return chats
+}
+
+// Peek возвращает отложенные сообщения чата, не удаляя их из хранилища
+func (s *HeldMessageStore) Peek(chatID string) []domain.HeldMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.messages[chatID])
+}
+
+// Ack удаляет из хранилища доставленные сообщения чата
+func (s *HeldMessageStore) Ack(chatID string, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := s.messages[chatID]
+	remaining := slices.DeleteFunc(slices.Clone(messages), func(msg domain.HeldMessage) bool {
+		return slices.Contains(ids, msg.ID)
+	})
+	if len(remaining) == len(messages) {
+		return nil
+	}
+
+	if len(remaining) == 0 {
+		delete(s.messages, chatID)
+	} else {
+		s.messages[chatID] = remaining
+	}
+	if err := s.flush(); err != nil {
+		s.messages[chatID] = messages
+		return err
+	}
+
+	return nil
+}
+
+// flush атомарно записывает состояние на диск (вызывается под мьютексом)
+func (s *HeldMessageStore) flush() error {
+	data, err := json.Marshal(s.messages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal held messages: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы не повредить данные при падении
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write held messages: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace held messages file: %w", err)
+	}
+
+	return nil
+}
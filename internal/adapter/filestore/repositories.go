@@ -0,0 +1,42 @@
+package filestore
+
+import (
+	"context"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/pkg/config"
+)
+
+// RepositoryFile - реестр репозиториев из repositories.json. Файл редактируется вручную
+// и перечитывается при изменении, поэтому изменения через API не поддерживаются.
+type RepositoryFile struct {
+	path string
+}
+
+func NewRepositoryFile(path string) *RepositoryFile {
+	return &RepositoryFile{path: path}
+}
+
+func (f *RepositoryFile) List(context.Context) (domain.RepositorySet, error) {
+	repositories, err := config.LoadRepositoriesFile(f.path)
+	if err != nil {
+		return domain.RepositorySet{}, err
+	}
+	return repositories.Set(), nil
+}
+
+func (f *RepositoryFile) Create(context.Context, domain.Repository) error {
+	return domain.ErrRegistryReadOnly
+}
+
+func (f *RepositoryFile) Update(context.Context, domain.RouteKey, domain.Repository) error {
+	return domain.ErrRegistryReadOnly
+}
+
+func (f *RepositoryFile) Delete(context.Context, domain.RouteKey) error {
+	return domain.ErrRegistryReadOnly
+}
+
+func (f *RepositoryFile) SetDefaultRoute(context.Context, *domain.Repository) error {
+	return domain.ErrRegistryReadOnly
+}
@@ -0,0 +1,463 @@
+package filestore
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// WALSync - когда журнал очереди сбрасывается на диск (fsync)
+type WALSync int
+
+const (
+	// WALSyncAlways - после каждой записи: принятое уведомление не теряется даже при сбое питания
+	WALSyncAlways WALSync = iota
+	// WALSyncInterval - периодически (WALOptions.SyncInterval): при сбое питания теряются записи за интервал
+	WALSyncInterval
+	// WALSyncNever - сброс выполняет ОС; переживает падение процесса, но не сбой питания
+	WALSyncNever
+)
+
+// WALOptions - параметры журнала очереди
+type WALOptions struct {
+	// SegmentSize - размер сегмента в байтах, после которого запись продолжается в новом файле
+	SegmentSize  int64
+	Sync         WALSync
+	SyncInterval time.Duration
+}
+
+// Типы записей журнала
+const (
+	walAppend byte = 1
+	walAck    byte = 2
+)
+
+const (
+	walSegmentExt = ".wal"
+	// walHeaderSize - длина и CRC32 тела записи
+	walHeaderSize = 8
+	// walMaxRecord - защита от чтения мусора как длины записи
+	walMaxRecord = 64 << 20
+)
+
+// WALQueue - хранилище очереди доставки в журнале упреждающей записи (write-ahead log) на диске.
+// Журнал - последовательность сегментов <номер>.wal, в которые только дописываются записи
+// "добавлено уведомление" и "уведомление обработано". Сегмент удаляется, когда все уведомления
+// в нём и во всех предыдущих сегментах обработаны. При открытии журнал перечитывается,
+// и необработанные уведомления возвращаются в очередь.
+//
+// В журнал попадает только само уведомление: обработчики Done и Sent не сохраняются,
+// поэтому у восстановленных уведомлений их нет (например, итог доставки в истории событий
+// для них останется pending).
+type WALQueue struct {
+	mu   sync.Mutex
+	dir  string
+	opts WALOptions
+
+	file    *os.File
+	segment uint64
+	size    int64
+	dirty   bool
+	closed  bool
+
+	nextID uint64
+	// segments - номера сегментов по возрастанию; live - число необработанных уведомлений в сегменте
+	segments []uint64
+	live     map[uint64]int
+	// location - сегмент, в котором записано необработанное уведомление
+	location map[uint64]uint64
+	pending  []domain.QueuedNotification
+}
+
+// OpenWALQueue открывает журнал в dir (создаёт каталог при необходимости) и восстанавливает
+// необработанные уведомления; запись продолжается в новом сегменте
+func OpenWALQueue(dir string, opts WALOptions) (*WALQueue, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	q := &WALQueue{
+		dir:      dir,
+		opts:     opts,
+		nextID:   1,
+		live:     make(map[uint64]int),
+		location: make(map[uint64]uint64),
+	}
+
+	if err := q.replay(); err != nil {
+		return nil, err
+	}
+
+	next := uint64(1)
+	if len(q.segments) > 0 {
+		next = q.segments[len(q.segments)-1] + 1
+	}
+	if err := q.openSegment(next); err != nil {
+		return nil, err
+	}
+	q.compact()
+
+	return q, nil
+}
+
+// Append дописывает уведомление в журнал (реализует usecase.QueueStore)
+func (q *WALQueue) Append(n domain.Notification) (uint64, error) {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return 0, errors.New("delivery queue journal is closed")
+	}
+
+	if q.size >= q.opts.SegmentSize {
+		if err := q.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	id := q.nextID
+	if err := q.write(walAppend, id, payload); err != nil {
+		return 0, err
+	}
+	q.nextID++
+	q.live[q.segment]++
+	q.location[id] = q.segment
+
+	return id, nil
+}
+
+// Ack записывает, что уведомление обработано, и удаляет сегменты без необработанных уведомлений
+func (q *WALQueue) Ack(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	segment, ok := q.location[id]
+	if !ok {
+		return nil
+	}
+	if q.closed {
+		// Уведомление будет доставлено повторно после перезапуска
+		return errors.New("delivery queue journal is closed")
+	}
+
+	if err := q.write(walAck, id, nil); err != nil {
+		return err
+	}
+	delete(q.location, id)
+	q.live[segment]--
+	q.compact()
+
+	return nil
+}
+
+// Pending возвращает уведомления, не обработанные до остановки. Они отдаются один раз:
+// дальше очередь держит их в памяти.
+func (q *WALQueue) Pending() ([]domain.QueuedNotification, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := q.pending
+	q.pending = nil
+	return pending, nil
+}
+
+// Run периодически сбрасывает журнал на диск (для WALSyncInterval) до отмены контекста
+func (q *WALQueue) Run(ctx context.Context) {
+	if q.opts.Sync != WALSyncInterval {
+		return
+	}
+
+	ticker := time.NewTicker(q.opts.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.mu.Lock()
+			if err := q.sync(); err != nil {
+				log.Printf("❌ Не удалось сбросить журнал очереди доставки на диск: %v", err)
+			}
+			q.mu.Unlock()
+		}
+	}
+}
+
+// Close сбрасывает журнал на диск и закрывает текущий сегмент
+func (q *WALQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+
+	if err := q.file.Sync(); err != nil {
+		q.file.Close()
+		return fmt.Errorf("failed to sync queue journal: %w", err)
+	}
+	return q.file.Close()
+}
+
+// write кодирует запись как [длина тела][CRC32 тела][тип][ID][данные] и дописывает её одним вызовом
+func (q *WALQueue) write(kind byte, id uint64, payload []byte) error {
+	body := 1 + 8 + len(payload)
+	record := make([]byte, walHeaderSize+body)
+	record[walHeaderSize] = kind
+	binary.BigEndian.PutUint64(record[walHeaderSize+1:], id)
+	copy(record[walHeaderSize+9:], payload)
+	binary.BigEndian.PutUint32(record[0:], uint32(body))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(record[walHeaderSize:]))
+
+	prev := q.size
+	n, err := q.file.Write(record)
+	q.size += int64(n)
+	if err != nil {
+		q.discard(prev)
+		return fmt.Errorf("failed to write queue journal: %w", err)
+	}
+
+	q.dirty = true
+	if q.opts.Sync == WALSyncAlways {
+		if err := q.sync(); err != nil {
+			q.discard(prev)
+			return fmt.Errorf("failed to sync queue journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// discard убирает из сегмента запись, которую не удалось записать целиком или сбросить на диск:
+// вызывающий получил ошибку, и после перезапуска запись не должна восстановиться. Если обрезать
+// сегмент не удалось, запись продолжается в новом: replay пропускает оборванный конец сегмента,
+// но всё, что записано в сегмент после обрыва, было бы потеряно.
+func (q *WALQueue) discard(size int64) {
+	err := q.file.Truncate(size)
+	if err == nil {
+		q.size = size
+		return
+	}
+	log.Printf("⚠️ Не удалось обрезать сегмент журнала очереди %d, запись продолжится в новом: %v", q.segment, err)
+
+	q.file.Close()
+	q.dirty = false
+	if err := q.openSegment(q.segment + 1); err != nil {
+		log.Printf("❌ Не удалось открыть новый сегмент журнала очереди: %v", err)
+	}
+}
+
+func (q *WALQueue) sync() error {
+	if !q.dirty || q.closed {
+		return nil
+	}
+	if err := q.file.Sync(); err != nil {
+		return err
+	}
+	q.dirty = false
+	return nil
+}
+
+// rotate закрывает заполненный сегмент и начинает следующий
+func (q *WALQueue) rotate() error {
+	if err := q.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync queue journal: %w", err)
+	}
+	if err := q.file.Close(); err != nil {
+		return fmt.Errorf("failed to close queue journal segment: %w", err)
+	}
+	q.dirty = false
+
+	if err := q.openSegment(q.segment + 1); err != nil {
+		return err
+	}
+	q.compact()
+	return nil
+}
+
+func (q *WALQueue) openSegment(segment uint64) error {
+	f, err := os.OpenFile(q.segmentPath(segment), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open queue journal segment: %w", err)
+	}
+	// Без сброса каталога новый файл может пропасть при сбое питания вместе со сброшенными в него записями
+	if err := q.syncDir(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync queue directory: %w", err)
+	}
+
+	q.file = f
+	q.segment = segment
+	q.size = 0
+	q.segments = append(q.segments, segment)
+	return nil
+}
+
+// compact удаляет сегменты с начала журнала, пока в них нет необработанных уведомлений.
+// Удалять можно только с начала: в более новом сегменте могут быть записи об обработке
+// уведомлений из старых.
+func (q *WALQueue) compact() {
+	removed := false
+	defer func() {
+		// Удаление сбрасывается на диск, чтобы после сбоя питания удалённые сегменты не вернулись
+		// и их уведомления не были доставлены повторно
+		if !removed {
+			return
+		}
+		if err := q.syncDir(); err != nil {
+			log.Printf("❌ Не удалось сбросить каталог журнала очереди на диск: %v", err)
+		}
+	}()
+
+	for len(q.segments) > 0 {
+		segment := q.segments[0]
+		if segment == q.segment || q.live[segment] > 0 {
+			return
+		}
+
+		if err := os.Remove(q.segmentPath(segment)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("❌ Не удалось удалить сегмент журнала очереди %d: %v", segment, err)
+			return
+		}
+		removed = true
+		delete(q.live, segment)
+		q.segments = q.segments[1:]
+	}
+}
+
+// syncDir сбрасывает на диск каталог журнала: создание и удаление файлов сегментов
+// переживают сбой питания только после этого. При WALSyncNever сброс оставлен ОС.
+func (q *WALQueue) syncDir() error {
+	if q.opts.Sync == WALSyncNever {
+		return nil
+	}
+
+	dir, err := os.Open(q.dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// replay перечитывает сегменты по порядку и собирает необработанные уведомления
+func (q *WALQueue) replay() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read queue directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), walSegmentExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		segment, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, segment)
+	}
+	slices.Sort(q.segments)
+
+	appended := make(map[uint64]domain.Notification)
+	for _, segment := range q.segments {
+		if err := q.replaySegment(segment, appended); err != nil {
+			return err
+		}
+	}
+
+	for id, n := range appended {
+		q.pending = append(q.pending, domain.QueuedNotification{ID: id, Notification: n})
+	}
+	slices.SortFunc(q.pending, func(a, b domain.QueuedNotification) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return nil
+}
+
+func (q *WALQueue) replaySegment(segment uint64, appended map[uint64]domain.Notification) error {
+	f, err := os.Open(q.segmentPath(segment))
+	if err != nil {
+		return fmt.Errorf("failed to open queue journal segment: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	header := make([]byte, walHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return q.truncated(segment, err)
+		}
+
+		size := binary.BigEndian.Uint32(header[0:])
+		if size < 9 || size > walMaxRecord {
+			return q.truncated(segment, fmt.Errorf("invalid record size %d", size))
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return q.truncated(segment, err)
+		}
+		if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:]) {
+			return q.truncated(segment, errors.New("checksum mismatch"))
+		}
+
+		id := binary.BigEndian.Uint64(body[1:])
+		q.nextID = max(q.nextID, id+1)
+
+		switch body[0] {
+		case walAppend:
+			var n domain.Notification
+			if err := json.Unmarshal(body[9:], &n); err != nil {
+				log.Printf("⚠️ Пропущено повреждённое уведомление #%d в журнале очереди: %v", id, err)
+				continue
+			}
+			appended[id] = n
+			q.live[segment]++
+			q.location[id] = segment
+		case walAck:
+			if s, ok := q.location[id]; ok {
+				delete(appended, id)
+				delete(q.location, id)
+				q.live[s]--
+			}
+		}
+	}
+}
+
+// truncated сообщает о недописанной записи: так обрывается журнал при падении процесса
+// посреди записи. Записи до неё восстанавливаются, а сегмент удалится, когда они будут обработаны.
+func (q *WALQueue) truncated(segment uint64, err error) error {
+	log.Printf("⚠️ Сегмент журнала очереди %d оборван, остаток пропущен: %v", segment, err)
+	return nil
+}
+
+func (q *WALQueue) segmentPath(segment uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", segment, walSegmentExt))
+}
@@ -0,0 +1,194 @@
+package filestore_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/filestore"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+func openWAL(t *testing.T, dir string, segmentSize int64) *filestore.WALQueue {
+	t.Helper()
+
+	q, err := filestore.OpenWALQueue(dir, filestore.WALOptions{SegmentSize: segmentSize, Sync: filestore.WALSyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func appendWAL(t *testing.T, q *filestore.WALQueue, messages ...string) []uint64 {
+	t.Helper()
+
+	var ids []uint64
+	for _, m := range messages {
+		id, err := q.Append(domain.Notification{ChatID: "-1", Message: m})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func pendingMessages(t *testing.T, q *filestore.WALQueue) []string {
+	t.Helper()
+
+	pending, err := q.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, n := range pending {
+		messages = append(messages, n.Notification.Message)
+	}
+	return messages
+}
+
+// segmentFiles возвращает файлы сегментов журнала по порядку
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	return files
+}
+
+func TestWALQueueReplaysUnackedAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	q := openWAL(t, dir, 1<<20)
+	ids := appendWAL(t, q, "a", "b", "c")
+	if err := q.Ack(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = openWAL(t, dir, 1<<20)
+	defer q.Close()
+
+	pending, err := q.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].ID != ids[0] || pending[1].ID != ids[2] ||
+		pending[0].Notification.Message != "a" || pending[1].Notification.Message != "c" {
+		t.Fatalf("Pending() = %+v, want unacked a and c with their IDs", pending)
+	}
+	if again := pendingMessages(t, q); len(again) != 0 {
+		t.Errorf("second Pending() = %q, want nothing", again)
+	}
+
+	// Номера не повторяются после перезапуска, иначе Ack нового уведомления подтвердил бы старое
+	if id := appendWAL(t, q, "d")[0]; id <= ids[2] {
+		t.Errorf("Append() after reopen = %d, want greater than %d", id, ids[2])
+	}
+}
+
+func TestWALQueueDropsBrokenLastRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+		want    []string
+	}{
+		{
+			name:    "truncated last record",
+			corrupt: func(data []byte) []byte { return data[:len(data)-3] },
+			want:    []string{"a"},
+		},
+		{
+			name:    "truncated header after last record",
+			corrupt: func(data []byte) []byte { return append(data, 0, 0, 1) },
+			want:    []string{"a", "b"},
+		},
+		{
+			name: "corrupt last record",
+			corrupt: func(data []byte) []byte {
+				data[len(data)-1] ^= 0xff
+				return data
+			},
+			want: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			q := openWAL(t, dir, 1<<20)
+			appendWAL(t, q, "a", "b")
+			if err := q.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			files := segmentFiles(t, dir)
+			if len(files) != 1 {
+				t.Fatalf("segments = %q, want one", files)
+			}
+			data, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(files[0], tt.corrupt(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			q = openWAL(t, dir, 1<<20)
+			defer q.Close()
+
+			if got := pendingMessages(t, q); !slices.Equal(got, tt.want) {
+				t.Errorf("Pending() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWALQueueRemovesAckedSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	// Каждое уведомление после первого начинает новый сегмент
+	q := openWAL(t, dir, 1)
+	ids := appendWAL(t, q, "a", "b", "c")
+	if files := segmentFiles(t, dir); len(files) != 3 {
+		t.Fatalf("segments after rollover = %d, want 3", len(files))
+	}
+
+	steps := []struct {
+		ack  uint64
+		want int
+	}{
+		{ack: ids[0], want: 2},
+		// Сегмент уведомления c - текущий, а в сегменте b есть необработанное уведомление
+		{ack: ids[2], want: 2},
+		{ack: ids[1], want: 1},
+	}
+	for _, step := range steps {
+		if err := q.Ack(step.ack); err != nil {
+			t.Fatal(err)
+		}
+		if files := segmentFiles(t, dir); len(files) != step.want {
+			t.Errorf("segments after Ack(%d) = %d, want %d", step.ack, len(files), step.want)
+		}
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = openWAL(t, dir, 1)
+	defer q.Close()
+
+	if got := pendingMessages(t, q); len(got) != 0 {
+		t.Errorf("Pending() after all acks = %q, want nothing", got)
+	}
+	if files := segmentFiles(t, dir); len(files) != 1 {
+		t.Errorf("segments after reopen = %q, want only the new one", files)
+	}
+}
@@ -2,13 +2,19 @@ package gitlab
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sensetion/tgGitlabBot/internal/domain"
 	"github.com/sensetion/tgGitlabBot/pkg/logger"
 )
 
+// ErrUnsupportedEvent - тип хука не поддерживается ботом
+var ErrUnsupportedEvent = errors.New("unsupported event")
+
 type Parser struct{}
 
 func NewParser() *Parser {
@@ -24,6 +30,79 @@ type pushEventPayload struct {
 	Commits      []commit    `json:"commits"`
 }
 
+type pipelineEventPayload struct {
+	ObjectKind       string      `json:"object_kind"`
+	User             userInfo    `json:"user"`
+	Project          projectInfo `json:"project"`
+	ObjectAttributes struct {
+		ID         int    `json:"id"`
+		Ref        string `json:"ref"`
+		Status     string `json:"status"`
+		URL        string `json:"url"`
+		FinishedAt string `json:"finished_at"`
+		CreatedAt  string `json:"created_at"`
+	} `json:"object_attributes"`
+	// MergeRequest заполнен у пайплайнов, запущенных для merge request
+	MergeRequest *struct {
+		IID int `json:"iid"`
+	} `json:"merge_request"`
+	Commit commit `json:"commit"`
+}
+
+type mergeRequestEventPayload struct {
+	ObjectKind       string      `json:"object_kind"`
+	User             userInfo    `json:"user"`
+	Project          projectInfo `json:"project"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		State        string `json:"state"`
+		Action       string `json:"action"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		URL          string `json:"url"`
+		UpdatedAt    string `json:"updated_at"`
+	} `json:"object_attributes"`
+}
+
+type issueEventPayload struct {
+	ObjectKind       string      `json:"object_kind"`
+	User             userInfo    `json:"user"`
+	Project          projectInfo `json:"project"`
+	ObjectAttributes struct {
+		IID       int    `json:"iid"`
+		Title     string `json:"title"`
+		State     string `json:"state"`
+		Action    string `json:"action"`
+		URL       string `json:"url"`
+		UpdatedAt string `json:"updated_at"`
+	} `json:"object_attributes"`
+}
+
+type noteEventPayload struct {
+	ObjectKind       string      `json:"object_kind"`
+	User             userInfo    `json:"user"`
+	Project          projectInfo `json:"project"`
+	ObjectAttributes struct {
+		ID int `json:"id"`
+		// NoteableType - к чему оставлен комментарий: MergeRequest, Issue, Commit, Snippet
+		NoteableType string `json:"noteable_type"`
+		Note         string `json:"note"`
+		URL          string `json:"url"`
+		CreatedAt    string `json:"created_at"`
+	} `json:"object_attributes"`
+	MergeRequest *struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		TargetBranch string `json:"target_branch"`
+	} `json:"merge_request"`
+	Issue *struct {
+		IID   int    `json:"iid"`
+		Title string `json:"title"`
+	} `json:"issue"`
+	Commit *commit `json:"commit"`
+}
+
 type projectInfo struct {
 	ID                int    `json:"id"`
 	Name              string `json:"name"`
@@ -31,12 +110,21 @@ type projectInfo struct {
 	WebURL            string `json:"web_url"`
 }
 
+type userInfo struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
 type commit struct {
 	ID        string    `json:"id"`
 	Message   string    `json:"message"`
 	Timestamp time.Time `json:"timestamp"`
 	URL       string    `json:"url"`
 	Author    author    `json:"author"`
+	Added     []string  `json:"added"`
+	Modified  []string  `json:"modified"`
+	Removed   []string  `json:"removed"`
 }
 
 type author struct {
@@ -44,6 +132,72 @@ type author struct {
 	Email string `json:"email"`
 }
 
+// Parse разбирает хук GitLab любого поддерживаемого типа в нормализованное событие
+func (p *Parser) Parse(payload []byte) (*domain.Event, error) {
+	var header struct {
+		ObjectKind string `json:"object_kind"`
+	}
+	if err := json.Unmarshal(payload, &header); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	switch domain.EventKind(header.ObjectKind) {
+	case domain.EventKindPush:
+		push, err := p.ParsePushEvent(payload)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.Event{
+			Kind:        domain.EventKindPush,
+			ProjectID:   push.RepositoryID,
+			ProjectName: push.RepositoryName,
+			ProjectURL:  push.WebURL,
+			Ref:         push.Branch,
+			Author:      push.Author,
+			Title:       firstLine(push.CommitMsg),
+			URL:         push.CommitURL,
+			Timestamp:   push.Timestamp,
+			Push:        push,
+		}, nil
+
+	case domain.EventKindPipeline:
+		return p.parsePipelineEvent(payload)
+
+	case domain.EventKindMergeRequest:
+		return p.parseMergeRequestEvent(payload)
+
+	case domain.EventKindIssue:
+		return p.parseIssueEvent(payload)
+
+	case domain.EventKindNote:
+		return p.parseNoteEvent(payload)
+	}
+
+	return nil, fmt.Errorf("%w: object_kind %q", ErrUnsupportedEvent, header.ObjectKind)
+}
+
+// ProjectID извлекает ID проекта из хука без полного разбора события.
+// Используется для выбора секрета вебхука до аутентификации запроса.
+func ProjectID(payload []byte) (string, error) {
+	var header struct {
+		ProjectID int         `json:"project_id"`
+		Project   projectInfo `json:"project"`
+	}
+	if err := json.Unmarshal(payload, &header); err != nil {
+		return "", fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	id := header.Project.ID
+	if id == 0 {
+		id = header.ProjectID
+	}
+	if id == 0 {
+		return "", errors.New("project id is missing in payload")
+	}
+
+	return strconv.Itoa(id), nil
+}
+
 func (p *Parser) ParsePushEvent(payload []byte) (*domain.CommitEvent, error) {
 	var event pushEventPayload
 	if err := json.Unmarshal(payload, &event); err != nil {
@@ -75,12 +229,181 @@ func (p *Parser) ParsePushEvent(payload []byte) (*domain.CommitEvent, error) {
 		Timestamp:      lastCommit.Timestamp,
 		WebURL:         event.Project.WebURL,
 		CommitURL:      lastCommit.URL,
+		CommitsCount:   len(event.Commits),
+		ChangedFiles:   p.collectChangedFiles(event.Commits),
+	}, nil
+}
+
+func (p *Parser) parsePipelineEvent(payload []byte) (*domain.Event, error) {
+	var event pipelineEventPayload
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pipeline payload: %w", err)
+	}
+
+	attrs := event.ObjectAttributes
+
+	// finished_at пуст у незавершённых пайплайнов
+	timestamp := parseTime(attrs.FinishedAt)
+	if timestamp.IsZero() {
+		timestamp = parseTime(attrs.CreatedAt)
+	}
+
+	url := attrs.URL
+	if url == "" {
+		url = fmt.Sprintf("%s/-/pipelines/%d", event.Project.WebURL, attrs.ID)
+	}
+
+	var mergeRequestIID int
+	if event.MergeRequest != nil {
+		mergeRequestIID = event.MergeRequest.IID
+	}
+
+	return &domain.Event{
+		Kind:        domain.EventKindPipeline,
+		ProjectID:   fmt.Sprintf("%d", event.Project.ID),
+		ProjectName: event.Project.PathWithNamespace,
+		ProjectURL:  event.Project.WebURL,
+		Ref:         attrs.Ref,
+		Status:      attrs.Status,
+		Author:      event.User.Name,
+		Title:       firstLine(event.Commit.Message),
+		URL:         url,
+		ObjectID:    attrs.ID,
+		Timestamp:   timestamp,
+		// Пайплайн merge request отправляется в ветку сообщений merge request
+		MergeRequestIID: mergeRequestIID,
+	}, nil
+}
+
+func (p *Parser) parseMergeRequestEvent(payload []byte) (*domain.Event, error) {
+	var event mergeRequestEventPayload
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal merge request payload: %w", err)
+	}
+
+	attrs := event.ObjectAttributes
+
+	return &domain.Event{
+		Kind:        domain.EventKindMergeRequest,
+		ProjectID:   fmt.Sprintf("%d", event.Project.ID),
+		ProjectName: event.Project.PathWithNamespace,
+		ProjectURL:  event.Project.WebURL,
+		Ref:         attrs.TargetBranch,
+		Status:      attrs.State,
+		Action:      attrs.Action,
+		Author:      event.User.Name,
+		Title:       attrs.Title,
+		URL:         attrs.URL,
+		ObjectID:    attrs.IID,
+		Timestamp:   parseTime(attrs.UpdatedAt),
+	}, nil
+}
+
+func (p *Parser) parseIssueEvent(payload []byte) (*domain.Event, error) {
+	var event issueEventPayload
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal issue payload: %w", err)
+	}
+
+	attrs := event.ObjectAttributes
+
+	return &domain.Event{
+		Kind:        domain.EventKindIssue,
+		ProjectID:   fmt.Sprintf("%d", event.Project.ID),
+		ProjectName: event.Project.PathWithNamespace,
+		ProjectURL:  event.Project.WebURL,
+		Status:      attrs.State,
+		Action:      attrs.Action,
+		Author:      event.User.Name,
+		Title:       attrs.Title,
+		URL:         attrs.URL,
+		ObjectID:    attrs.IID,
+		Timestamp:   parseTime(attrs.UpdatedAt),
 	}, nil
 }
 
+// parseNoteEvent разбирает комментарий. Комментарий к merge request или issue попадает в ветку
+// сообщений объекта; у комментария к merge request веткой события считается целевая ветка.
+func (p *Parser) parseNoteEvent(payload []byte) (*domain.Event, error) {
+	var event noteEventPayload
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal note payload: %w", err)
+	}
+
+	attrs := event.ObjectAttributes
+
+	result := &domain.Event{
+		Kind:        domain.EventKindNote,
+		ProjectID:   fmt.Sprintf("%d", event.Project.ID),
+		ProjectName: event.Project.PathWithNamespace,
+		ProjectURL:  event.Project.WebURL,
+		Action:      attrs.NoteableType,
+		Author:      event.User.Name,
+		URL:         attrs.URL,
+		ObjectID:    attrs.ID,
+		Note:        strings.TrimSpace(attrs.Note),
+		Timestamp:   parseTime(attrs.CreatedAt),
+	}
+
+	switch {
+	case attrs.NoteableType == "MergeRequest" && event.MergeRequest != nil:
+		result.MergeRequestIID = event.MergeRequest.IID
+		result.Title = event.MergeRequest.Title
+		result.Ref = event.MergeRequest.TargetBranch
+	case attrs.NoteableType == "Issue" && event.Issue != nil:
+		result.IssueIID = event.Issue.IID
+		result.Title = event.Issue.Title
+	case attrs.NoteableType == "Commit" && event.Commit != nil:
+		result.Title = firstLine(event.Commit.Message)
+	}
+
+	return result, nil
+}
+
+// collectChangedFiles собирает уникальные пути изменённых файлов из всех коммитов
+func (p *Parser) collectChangedFiles(commits []commit) []string {
+	seen := make(map[string]struct{})
+	files := make([]string, 0)
+
+	for _, c := range commits {
+		for _, list := range [][]string{c.Added, c.Modified, c.Removed} {
+			for _, file := range list {
+				if _, ok := seen[file]; ok {
+					continue
+				}
+				seen[file] = struct{}{}
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files
+}
+
 func (p *Parser) extractBranch(ref string) string {
 	if len(ref) > 11 && ref[:11] == "refs/heads/" {
 		return ref[11:]
 	}
 	return ref
 }
+
+// gitlabTimeLayouts - форматы времени, встречающиеся в разных типах хуков GitLab
+var gitlabTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -0700",
+}
+
+func parseTime(value string) time.Time {
+	for _, layout := range gitlabTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
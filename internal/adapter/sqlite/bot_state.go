@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Ключи таблицы bot_state
+const keyUpdateOffset = "telegram_update_offset"
+
+// BotState хранит служебное состояние бота в таблице bot_state
+type BotState struct {
+	db *sql.DB
+}
+
+func NewBotState(db *sql.DB) *BotState {
+	return &BotState{db: db}
+}
+
+// UpdateOffset возвращает номер следующего необработанного обновления Telegram; 0, если он не сохранён
+func (s *BotState) UpdateOffset(ctx context.Context) (int, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM bot_state WHERE key = ?`, keyUpdateOffset).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid update offset %q: %w", value, err)
+	}
+	return offset, nil
+}
+
+func (s *BotState) SetUpdateOffset(ctx context.Context, offset int) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO bot_state (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		keyUpdateOffset, strconv.Itoa(offset), formatTime(time.Now()))
+	return err
+}
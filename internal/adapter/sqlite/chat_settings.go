@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// ChatSettings хранит настройки чатов в таблице chat_settings
+type ChatSettings struct {
+	db *sql.DB
+}
+
+func NewChatSettings(db *sql.DB) *ChatSettings {
+	return &ChatSettings{db: db}
+}
+
+func (c *ChatSettings) List(ctx context.Context) ([]domain.ChatSettings, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT chat_id, language, timezone, verbosity, muted_until, default_thread,
+		updated_at FROM chat_settings ORDER BY chat_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat settings: %w", err)
+	}
+	defer rows.Close()
+
+	list := []domain.ChatSettings{}
+	for rows.Next() {
+		var (
+			settings              domain.ChatSettings
+			mutedUntil, updatedAt string
+		)
+		if err := rows.Scan(&settings.ChatID, &settings.Language, &settings.Timezone, &settings.Verbosity,
+			&mutedUntil, &settings.DefaultThread, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat settings: %w", err)
+		}
+		if mutedUntil != "" {
+			t := parseTime(mutedUntil)
+			settings.MutedUntil = &t
+		}
+		settings.UpdatedAt = parseTime(updatedAt)
+
+		list = append(list, settings)
+	}
+	return list, rows.Err()
+}
+
+func (c *ChatSettings) Put(ctx context.Context, settings domain.ChatSettings) error {
+	var mutedUntil string
+	if settings.MutedUntil != nil {
+		mutedUntil = formatTime(*settings.MutedUntil)
+	}
+
+	_, err := c.db.ExecContext(ctx, `INSERT INTO chat_settings (chat_id, language, timezone, verbosity, muted_until,
+		default_thread, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET language = excluded.language, timezone = excluded.timezone,
+		verbosity = excluded.verbosity, muted_until = excluded.muted_until,
+		default_thread = excluded.default_thread, updated_at = excluded.updated_at`,
+		settings.ChatID, settings.Language, settings.Timezone, string(settings.Verbosity), mutedUntil,
+		settings.DefaultThread, formatTime(settings.UpdatedAt))
+	return err
+}
+
+func (c *ChatSettings) Delete(ctx context.Context, chatID string) error {
+	result, err := c.db.ExecContext(ctx, `DELETE FROM chat_settings WHERE chat_id = ?`, chatID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrChatSettingsNotFound
+	}
+	return nil
+}
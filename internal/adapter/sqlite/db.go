@@ -0,0 +1,228 @@
+// Package sqlite - хранилища бота в SQLite (драйвер modernc.org/sqlite на чистом Go, без cgo)
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite" // Регистрирует драйвер "sqlite"
+)
+
+// migrations - схема базы по версиям; версия хранится в PRAGMA user_version.
+// Новые изменения схемы добавляются в конец списка, существующие не меняются.
+var migrations = []string{
+	// 1: реестр репозиториев
+	`CREATE TABLE repositories (
+		instance        TEXT    NOT NULL,
+		project_id      TEXT    NOT NULL,
+		chat_id         TEXT    NOT NULL,
+		branches        TEXT    NOT NULL DEFAULT '[]',
+		paths           TEXT    NOT NULL DEFAULT '[]',
+		show_files      INTEGER NOT NULL DEFAULT 0,
+		condition       TEXT    NOT NULL DEFAULT '',
+		webhook_secret  TEXT    NOT NULL DEFAULT '',
+		webhook_secrets TEXT    NOT NULL DEFAULT '[]',
+		enabled         INTEGER NOT NULL DEFAULT 1,
+		updated_at      TEXT    NOT NULL,
+		PRIMARY KEY (instance, project_id, chat_id)
+	);
+	CREATE TABLE default_route (
+		id         INTEGER PRIMARY KEY CHECK (id = 1),
+		route      TEXT    NOT NULL,
+		updated_at TEXT    NOT NULL
+	);`,
+	// 2: outbox принятых событий
+	`CREATE TABLE outbox (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		event           TEXT    NOT NULL,
+		status          TEXT    NOT NULL DEFAULT 'pending',
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		last_error      TEXT    NOT NULL DEFAULT '',
+		next_attempt_at TEXT    NOT NULL,
+		created_at      TEXT    NOT NULL,
+		updated_at      TEXT    NOT NULL
+	);
+	CREATE INDEX outbox_status ON outbox (status, next_attempt_at);`,
+	// 3: недоставленные уведомления
+	`CREATE TABLE dead_letters (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_id      TEXT    NOT NULL,
+		notification TEXT    NOT NULL,
+		error        TEXT    NOT NULL,
+		attempts     INTEGER NOT NULL,
+		created_at   TEXT    NOT NULL
+	);
+	CREATE INDEX dead_letters_chat ON dead_letters (chat_id);`,
+	// 4: история событий
+	`CREATE TABLE events (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		instance     TEXT    NOT NULL,
+		project_id   TEXT    NOT NULL,
+		project_name TEXT    NOT NULL,
+		kind         TEXT    NOT NULL,
+		ref          TEXT    NOT NULL,
+		author       TEXT    NOT NULL,
+		title        TEXT    NOT NULL,
+		status       TEXT    NOT NULL,
+		action       TEXT    NOT NULL,
+		url          TEXT    NOT NULL,
+		received_at  TEXT    NOT NULL,
+		outcome      TEXT    NOT NULL,
+		deliveries   TEXT    NOT NULL DEFAULT '[]'
+	);
+	CREATE INDEX events_project ON events (instance, project_id);
+	CREATE INDEX events_received_at ON events (received_at);`,
+	// 5: ветки сообщений: первое сообщение об объекте GitLab в чате
+	`CREATE TABLE threads (
+		chat_id    TEXT    NOT NULL,
+		thread     TEXT    NOT NULL,
+		message_id INTEGER NOT NULL,
+		expires_at TEXT    NOT NULL,
+		PRIMARY KEY (chat_id, thread)
+	);
+	CREATE INDEX threads_expires_at ON threads (expires_at);`,
+	// 6: настройки чатов
+	`CREATE TABLE chat_settings (
+		chat_id        TEXT    PRIMARY KEY,
+		language       TEXT    NOT NULL DEFAULT '',
+		timezone       TEXT    NOT NULL DEFAULT '',
+		verbosity      TEXT    NOT NULL DEFAULT '',
+		muted_until    TEXT    NOT NULL DEFAULT '',
+		default_thread INTEGER NOT NULL DEFAULT 0,
+		updated_at     TEXT    NOT NULL
+	);`,
+	// 7: служебное состояние бота (номер обновления Telegram)
+	`CREATE TABLE bot_state (
+		key        TEXT PRIMARY KEY,
+		value      TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);`,
+	// 8: чаты, в которые уведомления события outbox уже приняты очередью доставки
+	`ALTER TABLE outbox ADD COLUMN delivered_chats TEXT NOT NULL DEFAULT '[]';`,
+	// 9: время события в GitLab и запись outbox, повторы которой обновляют ту же запись истории
+	`ALTER TABLE events ADD COLUMN occurred_at TEXT NOT NULL DEFAULT '';
+	ALTER TABLE events ADD COLUMN outbox_id INTEGER NOT NULL DEFAULT 0;
+	UPDATE events SET occurred_at = received_at;
+	CREATE INDEX events_occurred_at ON events (occurred_at);
+	CREATE INDEX events_outbox ON events (outbox_id) WHERE outbox_id > 0;`,
+}
+
+// Open открывает (и при необходимости создаёт) базу и применяет миграции
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	// WAL позволяет читать во время записи; busy_timeout - ждать блокировку вместо ошибки SQLITE_BUSY
+	dsn := "file:" + path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	// SQLite допускает одного писателя: одно соединение исключает SQLITE_BUSY между своими запросами
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// OpenReadOnly открывает существующую базу только для чтения, не создавая её и не применяя миграции,
+// например, для выгрузки данных из базы работающего бота или из резервной копии
+func OpenReadOnly(ctx context.Context, path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+
+	dsn := "file:" + path + "?mode=ro&_pragma=busy_timeout(5000)&_pragma=query_only(1)"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA не поддерживает параметры запроса
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteBatch - сколько строк удаляется одним запросом: единственное соединение
+// не блокируется надолго, и доставка продолжается во время очистки
+const deleteBatch = 1000
+
+// deleteBatched удаляет строки table, подходящие под where, частями и возвращает их количество
+func deleteBatched(ctx context.Context, db *sql.DB, table, where string, args ...any) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE rowid IN (SELECT rowid FROM %s WHERE %s LIMIT %d)`,
+		table, table, where, deleteBatch)
+
+	total := 0
+	for {
+		result, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return total, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += int(n)
+		if n < deleteBatch {
+			return total, nil
+		}
+	}
+}
+
+// Compact переносит журнал WAL в основной файл и обрезает его; с vacuum также
+// перестраивает файл базы, возвращая место, освобождённое удалёнными строками
+func Compact(ctx context.Context, db *sql.DB, vacuum bool) error {
+	if vacuum {
+		if _, err := db.ExecContext(ctx, "VACUUM"); err != nil {
+			return fmt.Errorf("failed to vacuum database: %w", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	return nil
+}
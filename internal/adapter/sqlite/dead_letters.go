@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// DeadLetters хранит недоставленные уведомления в таблице dead_letters
+type DeadLetters struct {
+	db *sql.DB
+}
+
+func NewDeadLetters(db *sql.DB) *DeadLetters {
+	return &DeadLetters{db: db}
+}
+
+const deadLetterColumns = `id, notification, error, attempts, created_at`
+
+func (d *DeadLetters) Add(ctx context.Context, letter domain.DeadLetter) (int64, error) {
+	data, err := json.Marshal(letter.Notification)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	result, err := d.db.ExecContext(ctx, `INSERT INTO dead_letters (chat_id, notification, error, attempts, created_at)
+		VALUES (?, ?, ?, ?, ?)`, letter.Notification.ChatID, string(data), letter.Error, letter.Attempts,
+		formatTime(letter.CreatedAt))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (d *DeadLetters) List(ctx context.Context, chatID string, limit int) ([]domain.DeadLetter, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+deadLetterColumns+` FROM dead_letters
+		WHERE ? = '' OR chat_id = ? ORDER BY id DESC LIMIT ?`, chatID, chatID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	letters := []domain.DeadLetter{}
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, rows.Err()
+}
+
+func (d *DeadLetters) Get(ctx context.Context, id int64) (domain.DeadLetter, error) {
+	row := d.db.QueryRowContext(ctx, `SELECT `+deadLetterColumns+` FROM dead_letters WHERE id = ?`, id)
+
+	letter, err := scanDeadLetter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return letter, domain.ErrDeadLetterNotFound
+	}
+	return letter, err
+}
+
+func (d *DeadLetters) Delete(ctx context.Context, id int64) error {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+func (d *DeadLetters) Purge(ctx context.Context, chatID string) (int, error) {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE ? = '' OR chat_id = ?`, chatID, chatID)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// DeleteBefore удаляет сообщения, сохранённые раньше before
+func (d *DeadLetters) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	return deleteBatched(ctx, d.db, "dead_letters", "created_at < ?", formatTime(before))
+}
+
+func (d *DeadLetters) Count(ctx context.Context) (int, error) {
+	var n int
+	err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM dead_letters`).Scan(&n)
+	return n, err
+}
+
+func scanDeadLetter(row scanner) (domain.DeadLetter, error) {
+	var (
+		letter                  domain.DeadLetter
+		notification, createdAt string
+	)
+
+	if err := row.Scan(&letter.ID, &notification, &letter.Error, &letter.Attempts, &createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return letter, err
+		}
+		return letter, fmt.Errorf("failed to scan dead letter: %w", err)
+	}
+	if err := json.Unmarshal([]byte(notification), &letter.Notification); err != nil {
+		return letter, fmt.Errorf("failed to decode dead letter %d: %w", letter.ID, err)
+	}
+	letter.CreatedAt = parseTime(createdAt)
+
+	return letter, nil
+}
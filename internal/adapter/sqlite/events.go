@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// Events хранит историю событий в таблице events
+type Events struct {
+	db *sql.DB
+}
+
+func NewEvents(db *sql.DB) *Events {
+	return &Events{db: db}
+}
+
+const eventColumns = `id, instance, project_id, project_name, kind, ref, author, title, status, action, url,
+	occurred_at, received_at, outbox_id, outcome, deliveries`
+
+func (e *Events) Add(ctx context.Context, record domain.EventRecord) (int64, error) {
+	deliveries, err := json.Marshal(nonNilDeliveries(record.Deliveries))
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode deliveries: %w", err)
+	}
+
+	result, err := e.db.ExecContext(ctx, `INSERT INTO events (instance, project_id, project_name, kind, ref, author,
+		title, status, action, url, occurred_at, received_at, outbox_id, outcome, deliveries)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Instance, record.ProjectID, record.ProjectName, string(record.Kind), record.Ref, record.Author,
+		record.Title, record.Status, record.Action, record.URL, formatTime(record.OccurredAt), formatTime(record.ReceivedAt),
+		record.OutboxID, string(record.Outcome), string(deliveries))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (e *Events) Finish(ctx context.Context, id int64, outcome domain.EventOutcome, deliveries []domain.EventDelivery) error {
+	data, err := json.Marshal(nonNilDeliveries(deliveries))
+	if err != nil {
+		return fmt.Errorf("failed to encode deliveries: %w", err)
+	}
+
+	_, err = e.db.ExecContext(ctx, `UPDATE events SET outcome = ?, deliveries = ? WHERE id = ?`,
+		string(outcome), string(data), id)
+	return err
+}
+
+func (e *Events) Query(ctx context.Context, filter domain.EventFilter) ([]domain.EventRecord, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, values ...any) {
+		where = append(where, cond)
+		args = append(args, values...)
+	}
+
+	if filter.Instance != "" {
+		add("instance = ?", filter.Instance)
+	}
+	if filter.Project != "" {
+		add("(project_id = ? OR project_name = ?)", filter.Project, filter.Project)
+	}
+	if filter.Ref != "" {
+		add("ref = ?", filter.Ref)
+	}
+	if filter.Author != "" {
+		add("author = ?", filter.Author)
+	}
+	if filter.Kind != "" {
+		add("kind = ?", string(filter.Kind))
+	}
+	if filter.Outcome != "" {
+		add("outcome = ?", string(filter.Outcome))
+	}
+	if !filter.Since.IsZero() {
+		add("occurred_at >= ?", formatTime(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		add("occurred_at < ?", formatTime(filter.Until))
+	}
+	if filter.Before > 0 {
+		add("id < ?", filter.Before)
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	records := []domain.EventRecord{}
+	for rows.Next() {
+		record, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// FindByOutbox возвращает последнюю запись о событии из записи outbox
+func (e *Events) FindByOutbox(ctx context.Context, outboxID int64) (domain.EventRecord, error) {
+	row := e.db.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE outbox_id = ? ORDER BY id DESC LIMIT 1`, outboxID)
+
+	record, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return record, domain.ErrEventNotFound
+	}
+	return record, err
+}
+
+func scanEvent(row interface{ Scan(dest ...any) error }) (domain.EventRecord, error) {
+	var (
+		record                             domain.EventRecord
+		occurredAt, receivedAt, deliveries string
+	)
+	if err := row.Scan(&record.ID, &record.Instance, &record.ProjectID, &record.ProjectName, &record.Kind,
+		&record.Ref, &record.Author, &record.Title, &record.Status, &record.Action, &record.URL,
+		&occurredAt, &receivedAt, &record.OutboxID, &record.Outcome, &deliveries); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record, err
+		}
+		return record, fmt.Errorf("failed to scan event: %w", err)
+	}
+	if err := json.Unmarshal([]byte(deliveries), &record.Deliveries); err != nil {
+		return record, fmt.Errorf("failed to decode event %d: %w", record.ID, err)
+	}
+	record.OccurredAt = parseTime(occurredAt)
+	record.ReceivedAt = parseTime(receivedAt)
+
+	return record, nil
+}
+
+func nonNilDeliveries(list []domain.EventDelivery) []domain.EventDelivery {
+	if list == nil {
+		return []domain.EventDelivery{}
+	}
+	return list
+}
+
+// DeleteBefore удаляет события, полученные раньше before
+func (e *Events) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	return deleteBatched(ctx, e.db, "events", "received_at < ?", formatTime(before))
+}
@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Форматы выгрузки
+const (
+	ExportJSONL = "jsonl"
+	ExportCSV   = "csv"
+)
+
+// exportTable - таблица, доступная для выгрузки
+type exportTable struct {
+	// timeColumn - колонка времени для фильтра по периоду
+	timeColumn string
+	// jsonColumns - колонки с JSON: в JSONL они вкладываются объектами, а не строками
+	jsonColumns []string
+}
+
+var exportTables = map[string]exportTable{
+	"events":       {timeColumn: "received_at", jsonColumns: []string{"deliveries"}},
+	"outbox":       {timeColumn: "created_at", jsonColumns: []string{"event", "delivered_chats"}},
+	"dead_letters": {timeColumn: "created_at", jsonColumns: []string{"notification"}},
+	"threads":      {timeColumn: "expires_at"},
+}
+
+// ExportTables возвращает названия таблиц, доступных для выгрузки
+func ExportTables() []string {
+	tables := make([]string, 0, len(exportTables))
+	for name := range exportTables {
+		tables = append(tables, name)
+	}
+	slices.Sort(tables)
+	return tables
+}
+
+// Export выгружает строки таблицы за период since <= время < until (нулевое значение - без границы)
+// в формате JSONL или CSV с заголовком и возвращает количество строк. Строки выгружаются в порядке добавления.
+func Export(ctx context.Context, db *sql.DB, table, format string, since, until time.Time, w io.Writer) (int, error) {
+	spec, ok := exportTables[table]
+	if !ok {
+		return 0, fmt.Errorf("unknown table %q, expected one of %s", table, strings.Join(ExportTables(), ", "))
+	}
+
+	var write func(columns []string, values []any) error
+	var flush func() error
+	switch format {
+	case ExportJSONL:
+		bw := bufio.NewWriter(w)
+		write = func(columns []string, values []any) error {
+			return writeJSONLine(bw, columns, values, spec.jsonColumns)
+		}
+		flush = bw.Flush
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		header := false
+		write = func(columns []string, values []any) error {
+			if !header {
+				header = true
+				if err := cw.Write(columns); err != nil {
+					return err
+				}
+			}
+			return cw.Write(csvRecord(values))
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return 0, fmt.Errorf("unknown export format %q, expected %s or %s", format, ExportJSONL, ExportCSV)
+	}
+
+	var (
+		where []string
+		args  []any
+	)
+	if !since.IsZero() {
+		where = append(where, spec.timeColumn+" >= ?")
+		args = append(args, formatTime(since))
+	}
+	if !until.IsZero() {
+		where = append(where, spec.timeColumn+" < ?")
+		args = append(args, formatTime(until))
+	}
+
+	query := `SELECT * FROM ` + table
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	// rowid совпадает с id там, где он есть, и есть у таблиц без id (threads)
+	query += ` ORDER BY rowid`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return n, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		if err := write(columns, values); err != nil {
+			return n, fmt.Errorf("failed to write %s: %w", table, err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	return n, flush()
+}
+
+// writeJSONLine пишет строку как JSON-объект с колонками в порядке таблицы
+func writeJSONLine(w *bufio.Writer, columns []string, values []any, jsonColumns []string) error {
+	w.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		w.Write(key)
+		w.WriteByte(':')
+
+		value := values[i]
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		if s, ok := value.(string); ok && slices.Contains(jsonColumns, column) && json.Valid([]byte(s)) {
+			value = json.RawMessage(s)
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+	}
+	w.WriteByte('}')
+	return w.WriteByte('\n')
+}
+
+func csvRecord(values []any) []string {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			record[i] = v
+		case []byte:
+			record[i] = string(v)
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return record
+}
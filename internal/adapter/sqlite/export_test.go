@@ -0,0 +1,57 @@
+package sqlite_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/sqlite"
+)
+
+func TestExportReadOnlyThreads(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bot.db")
+	db, err := sqlite.Open(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	expiresAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	threads := sqlite.NewThreads(db)
+	if err := threads.Put(ctx, "-1", "gitlab/1/mr/5", 10, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := threads.Put(ctx, "-1", "gitlab/1/mr/6", 11, expiresAt.Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	ro, err := sqlite.OpenReadOnly(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+
+	var buf bytes.Buffer
+	n, err := sqlite.Export(ctx, ro, "threads", sqlite.ExportJSONL, time.Time{}, expiresAt.Add(time.Hour), &buf)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if n != 1 || !strings.Contains(buf.String(), `"thread":"gitlab/1/mr/5"`) {
+		t.Errorf("Export() = %d rows %q, want thread gitlab/1/mr/5", n, buf.String())
+	}
+
+	if _, err := ro.ExecContext(ctx, `DELETE FROM threads`); err == nil {
+		t.Error("read-only database accepted a write")
+	}
+}
+
+func TestOpenReadOnlyDoesNotCreateDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.db")
+	if _, err := sqlite.OpenReadOnly(context.Background(), path); err == nil {
+		t.Fatal("OpenReadOnly() of a missing database succeeded")
+	}
+}
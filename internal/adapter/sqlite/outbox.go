@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// Статусы записей outbox
+const (
+	outboxPending  = "pending"
+	outboxInFlight = "in_flight"
+	outboxDone     = "done"
+	// outboxFailed - запись не удалось прочитать; она больше не выбирается и остаётся для разбора
+	outboxFailed = "failed"
+)
+
+// Outbox хранит принятые события в таблице outbox
+type Outbox struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{db: db, now: time.Now}
+}
+
+func (o *Outbox) Add(ctx context.Context, event *domain.Event) (int64, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	now := formatTime(o.now())
+	result, err := o.db.ExecContext(ctx, `INSERT INTO outbox (event, status, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`, string(data), outboxPending, now, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Claim выбирает готовые записи. Запись, которую не удалось декодировать, отмечается failed
+// с описанием ошибки: иначе она выбиралась бы первой при каждом запросе и блокировала outbox.
+func (o *Outbox) Claim(ctx context.Context, limit int) ([]domain.OutboxEntry, error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := formatTime(o.now())
+	rows, err := tx.QueryContext(ctx, `SELECT id, event, attempts, last_error, delivered_chats, created_at FROM outbox
+		WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?`, outboxPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	var (
+		entries []domain.OutboxEntry
+		ids     []any
+		broken  = make(map[int64]error)
+	)
+	for rows.Next() {
+		var (
+			entry                       domain.OutboxEntry
+			event, delivered, createdAt string
+		)
+		if err := rows.Scan(&entry.ID, &event, &entry.Attempts, &entry.LastError, &delivered, &createdAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+		if err := errors.Join(
+			json.Unmarshal([]byte(event), &entry.Event),
+			json.Unmarshal([]byte(delivered), &entry.DeliveredChats),
+		); err != nil {
+			broken[entry.ID] = err
+			continue
+		}
+		entry.CreatedAt = parseTime(createdAt)
+
+		entries = append(entries, entry)
+		ids = append(ids, entry.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for id, decodeErr := range broken {
+		if _, err := tx.ExecContext(ctx, `UPDATE outbox SET status = ?, last_error = ?, updated_at = ? WHERE id = ?`,
+			outboxFailed, "failed to decode entry: "+decodeErr.Error(), now, id); err != nil {
+			return nil, fmt.Errorf("failed to mark outbox entry %d failed: %w", id, err)
+		}
+		log.Printf("❌ Запись outbox #%d повреждена и пропущена: %v", id, decodeErr)
+	}
+
+	if len(entries) > 0 {
+		args := append([]any{outboxInFlight, now}, ids...)
+		if _, err := tx.ExecContext(ctx, `UPDATE outbox SET status = ?, updated_at = ?
+			WHERE id IN (`+placeholders(len(ids))+`)`, args...); err != nil {
+			return nil, fmt.Errorf("failed to claim outbox entries: %w", err)
+		}
+	}
+
+	return entries, tx.Commit()
+}
+
+func (o *Outbox) Complete(ctx context.Context, id int64) error {
+	_, err := o.db.ExecContext(ctx, `UPDATE outbox SET status = ?, updated_at = ? WHERE id = ?`,
+		outboxDone, formatTime(o.now()), id)
+	return err
+}
+
+func (o *Outbox) Retry(ctx context.Context, id int64, delivered []string, cause error, next time.Time) error {
+	data, err := json.Marshal(nonNil(delivered))
+	if err != nil {
+		return err
+	}
+
+	_, err = o.db.ExecContext(ctx, `UPDATE outbox
+		SET status = ?, attempts = attempts + 1, last_error = ?, delivered_chats = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id = ?`, outboxPending, cause.Error(), string(data), formatTime(next), formatTime(o.now()), id)
+	return err
+}
+
+// Recover возвращает прерванные записи в ожидание и сообщает, сколько событий ждёт доставки
+func (o *Outbox) Recover(ctx context.Context) (int, error) {
+	if _, err := o.db.ExecContext(ctx, `UPDATE outbox SET status = ?, updated_at = ? WHERE status = ?`,
+		outboxPending, formatTime(o.now()), outboxInFlight); err != nil {
+		return 0, err
+	}
+
+	var pending int
+	err := o.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE status = ?`, outboxPending).Scan(&pending)
+	return pending, err
+}
+
+// DeleteDone удаляет записи, доставленные раньше before; недоставленные не удаляются
+func (o *Outbox) DeleteDone(ctx context.Context, before time.Time) (int, error) {
+	return deleteBatched(ctx, o.db, "outbox", "status = ? AND updated_at < ?", outboxDone, formatTime(before))
+}
+
+// formatTime хранит время в UTC с наносекундами: такие строки сравниваются лексикографически
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,76 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/sqlite"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+func TestOutboxClaimSkipsCorruptEntries(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	outbox := sqlite.NewOutbox(db)
+	var ids []int64
+	for _, project := range []string{"1", "2", "3", "4"} {
+		id, err := outbox.Add(ctx, &domain.Event{Kind: domain.EventKindPush, ProjectID: project})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Повреждены событие первой записи и список чатов третьей
+	if _, err := db.ExecContext(ctx, `UPDATE outbox SET event = '{"kind":' WHERE id = ?`, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE outbox SET delivered_chats = 'not json' WHERE id = ?`, ids[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := outbox.Claim(ctx, 10)
+	if err != nil {
+		t.Fatalf("Claim() error = %v, want corrupt entries to be skipped", err)
+	}
+	if len(entries) != 2 || entries[0].ID != ids[1] || entries[1].ID != ids[3] {
+		t.Fatalf("Claim() = %+v, want entries %d and %d", entries, ids[1], ids[3])
+	}
+	if entries[0].Event.ProjectID != "2" || entries[1].Event.ProjectID != "4" {
+		t.Errorf("claimed projects = %s, %s, want 2, 4", entries[0].Event.ProjectID, entries[1].Event.ProjectID)
+	}
+
+	for _, id := range []int64{ids[0], ids[2]} {
+		var status, lastError string
+		if err := db.QueryRowContext(ctx, `SELECT status, last_error FROM outbox WHERE id = ?`, id).Scan(&status, &lastError); err != nil {
+			t.Fatal(err)
+		}
+		if status != "failed" || !strings.Contains(lastError, "failed to decode entry") {
+			t.Errorf("entry %d: status = %q, last_error = %q, want failed with decode error", id, status, lastError)
+		}
+	}
+
+	// Повреждённые записи не выбираются снова и не возвращаются Recover
+	if _, err := outbox.Recover(ctx); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = outbox.Claim(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Claim() after Recover = %d entries, want 2 recovered in-flight entries", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.ID == ids[0] || entry.ID == ids[2] {
+			t.Errorf("corrupt entry %d claimed again", entry.ID)
+		}
+	}
+}
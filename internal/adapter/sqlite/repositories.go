@@ -0,0 +1,282 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// RepositoryRegistry хранит записи репозиториев в таблице repositories
+type RepositoryRegistry struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func NewRepositoryRegistry(db *sql.DB) *RepositoryRegistry {
+	return &RepositoryRegistry{db: db, now: time.Now}
+}
+
+// storedSecret - секрет в базе. domain.Secret при сериализации скрывает значение,
+// поэтому для хранения используется обычная строка.
+type storedSecret struct {
+	Secret    string     `json:"secret"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// storedRoute - маршрут по умолчанию в базе (без полей, относящихся к конкретному проекту)
+type storedRoute struct {
+	TelegramChatID string   `json:"telegram_channel_id"`
+	Branches       []string `json:"branches"`
+	Paths          []string `json:"paths,omitempty"`
+	ShowFiles      bool     `json:"show_files,omitempty"`
+	Condition      string   `json:"condition,omitempty"`
+	Enabled        bool     `json:"enabled"`
+}
+
+const repositoryColumns = `instance, project_id, chat_id, branches, paths, show_files, condition,
+	webhook_secret, webhook_secrets, enabled`
+
+func (r *RepositoryRegistry) List(ctx context.Context) (domain.RepositorySet, error) {
+	var set domain.RepositorySet
+
+	// rowid сохраняет порядок добавления, как порядок записей в repositories.json
+	rows, err := r.db.QueryContext(ctx, `SELECT `+repositoryColumns+` FROM repositories ORDER BY rowid`)
+	if err != nil {
+		return set, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		repo, err := scanRepository(rows)
+		if err != nil {
+			return set, err
+		}
+		set.Repositories = append(set.Repositories, repo)
+	}
+	if err := rows.Err(); err != nil {
+		return set, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	var data string
+	err = r.db.QueryRowContext(ctx, `SELECT route FROM default_route WHERE id = 1`).Scan(&data)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return set, fmt.Errorf("failed to read default route: %w", err)
+	default:
+		var stored storedRoute
+		if err := json.Unmarshal([]byte(data), &stored); err != nil {
+			return set, fmt.Errorf("failed to decode default route: %w", err)
+		}
+		set.DefaultRoute = &domain.Repository{
+			TelegramChatID: stored.TelegramChatID,
+			Branches:       stored.Branches,
+			Paths:          stored.Paths,
+			ShowFiles:      stored.ShowFiles,
+			Condition:      stored.Condition,
+			Enabled:        stored.Enabled,
+		}
+	}
+
+	return set, nil
+}
+
+func (r *RepositoryRegistry) Create(ctx context.Context, repo domain.Repository) error {
+	args, err := repositoryArgs(repo)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO repositories (`+repositoryColumns+`, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, append(args, r.timestamp())...)
+	if isConstraintError(err) {
+		return domain.ErrRepositoryExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create repository %s: %w", repo.RouteKey(), err)
+	}
+	return nil
+}
+
+func (r *RepositoryRegistry) Update(ctx context.Context, key domain.RouteKey, repo domain.Repository) error {
+	args, err := repositoryArgs(repo)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE repositories SET
+			instance = ?, project_id = ?, chat_id = ?, branches = ?, paths = ?, show_files = ?, condition = ?,
+			webhook_secret = ?, webhook_secrets = ?, enabled = ?, updated_at = ?
+		WHERE instance = ? AND project_id = ? AND chat_id = ?`,
+		append(args, r.timestamp(), key.Instance, key.ProjectID, key.ChatID)...)
+	if isConstraintError(err) {
+		return domain.ErrRepositoryExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update repository %s: %w", key, err)
+	}
+	return requireAffected(result)
+}
+
+func (r *RepositoryRegistry) Delete(ctx context.Context, key domain.RouteKey) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM repositories WHERE instance = ? AND project_id = ? AND chat_id = ?`,
+		key.Instance, key.ProjectID, key.ChatID)
+	if err != nil {
+		return fmt.Errorf("failed to delete repository %s: %w", key, err)
+	}
+	return requireAffected(result)
+}
+
+func (r *RepositoryRegistry) SetDefaultRoute(ctx context.Context, route *domain.Repository) error {
+	return r.setDefaultRoute(ctx, r.db, route)
+}
+
+// setDefaultRoute сохраняет маршрут по умолчанию через db - базу или транзакцию
+func (r *RepositoryRegistry) setDefaultRoute(ctx context.Context, db execer, route *domain.Repository) error {
+	if route == nil {
+		if _, err := db.ExecContext(ctx, `DELETE FROM default_route`); err != nil {
+			return fmt.Errorf("failed to delete default route: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(storedRoute{
+		TelegramChatID: route.TelegramChatID,
+		Branches:       route.Branches,
+		Paths:          route.Paths,
+		ShowFiles:      route.ShowFiles,
+		Condition:      route.Condition,
+		Enabled:        route.Enabled,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `INSERT INTO default_route (id, route, updated_at) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET route = excluded.route, updated_at = excluded.updated_at`,
+		string(data), r.timestamp())
+	if err != nil {
+		return fmt.Errorf("failed to save default route: %w", err)
+	}
+	return nil
+}
+
+// Import записывает набор репозиториев и маршрут по умолчанию в пустой реестр одной транзакцией
+func (r *RepositoryRegistry) Import(ctx context.Context, set domain.RepositorySet) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, repo := range set.Repositories {
+		args, err := repositoryArgs(repo)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO repositories (`+repositoryColumns+`, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, append(args, r.timestamp())...); err != nil {
+			return fmt.Errorf("failed to import repository %s: %w", repo.RouteKey(), err)
+		}
+	}
+
+	if err := r.setDefaultRoute(ctx, tx, set.DefaultRoute); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *RepositoryRegistry) timestamp() string {
+	return r.now().UTC().Format(time.RFC3339)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRepository(row scanner) (domain.Repository, error) {
+	var (
+		repo                         domain.Repository
+		branches, paths, secretsJSON string
+		webhookSecret                string
+	)
+
+	err := row.Scan(&repo.Instance, &repo.ID, &repo.TelegramChatID, &branches, &paths, &repo.ShowFiles,
+		&repo.Condition, &webhookSecret, &secretsJSON, &repo.Enabled)
+	if err != nil {
+		return repo, fmt.Errorf("failed to scan repository: %w", err)
+	}
+
+	var secrets []storedSecret
+	if err := errors.Join(
+		json.Unmarshal([]byte(branches), &repo.Branches),
+		json.Unmarshal([]byte(paths), &repo.Paths),
+		json.Unmarshal([]byte(secretsJSON), &secrets),
+	); err != nil {
+		return repo, fmt.Errorf("failed to decode repository %s: %w", repo.RouteKey(), err)
+	}
+
+	repo.WebhookSecret = domain.Secret(webhookSecret)
+	for _, s := range secrets {
+		repo.WebhookSecrets = append(repo.WebhookSecrets, domain.WebhookSecret{Secret: domain.Secret(s.Secret), ExpiresAt: s.ExpiresAt})
+	}
+	if repo.Instance == domain.DefaultInstance {
+		repo.Instance = ""
+	}
+
+	return repo, nil
+}
+
+// repositoryArgs возвращает значения столбцов repositoryColumns для записи
+func repositoryArgs(repo domain.Repository) ([]any, error) {
+	secrets := make([]storedSecret, 0, len(repo.WebhookSecrets))
+	for _, s := range repo.WebhookSecrets {
+		secrets = append(secrets, storedSecret{Secret: s.Secret.Value(), ExpiresAt: s.ExpiresAt})
+	}
+
+	branches, err := json.Marshal(nonNil(repo.Branches))
+	if err != nil {
+		return nil, err
+	}
+	paths, err := json.Marshal(nonNil(repo.Paths))
+	if err != nil {
+		return nil, err
+	}
+	secretsJSON, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	key := repo.RouteKey()
+	return []any{key.Instance, key.ProjectID, key.ChatID, string(branches), string(paths), repo.ShowFiles,
+		repo.Condition, repo.WebhookSecret.Value(), string(secretsJSON), repo.Enabled}, nil
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+func requireAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrRepositoryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Threads хранит соответствие веток сообщений первым сообщениям в таблице threads
+type Threads struct {
+	db *sql.DB
+}
+
+func NewThreads(db *sql.DB) *Threads {
+	return &Threads{db: db}
+}
+
+func (t *Threads) Get(ctx context.Context, chatID, thread string, now time.Time) (int, bool, error) {
+	var messageID int
+	err := t.db.QueryRowContext(ctx, `SELECT message_id FROM threads WHERE chat_id = ? AND thread = ? AND expires_at > ?`,
+		chatID, thread, formatTime(now)).Scan(&messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return messageID, true, nil
+}
+
+func (t *Threads) Put(ctx context.Context, chatID, thread string, messageID int, expiresAt time.Time) error {
+	_, err := t.db.ExecContext(ctx, `INSERT INTO threads (chat_id, thread, message_id, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chat_id, thread) DO UPDATE SET message_id = excluded.message_id, expires_at = excluded.expires_at`,
+		chatID, thread, messageID, formatTime(expiresAt))
+	return err
+}
+
+func (t *Threads) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := t.db.ExecContext(ctx, `DELETE FROM threads WHERE expires_at <= ?`, formatTime(now))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
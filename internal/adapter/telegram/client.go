@@ -0,0 +1,239 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+type Client struct {
+	// apiURL - адрес Bot API: https://api.telegram.org, свой сервер Bot API или тестовый
+	apiURL string
+	// token возвращает актуальный токен бота (может меняться при ротации секрета)
+	token      func() string
+	httpClient *http.Client
+	// pollClient - клиент для getUpdates без общего таймаута: ожидание ограничивается контекстом запроса
+	pollClient *http.Client
+	timeout    time.Duration
+	maxRetries int
+}
+
+func NewClient(apiURL string, token func() string, timeout time.Duration, maxRetries int) *Client {
+	return &Client{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: timeout},
+		pollClient: &http.Client{},
+		timeout:    timeout,
+		maxRetries: maxRetries,
+	}
+}
+
+type sendMessageRequest struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+	DisableNotification   bool   `json:"disable_notification,omitempty"`
+	MessageThreadID       int    `json:"message_thread_id,omitempty"`
+	ReplyToMessageID      int    `json:"reply_to_message_id,omitempty"`
+	// AllowSendingWithoutReply - отправить сообщение, даже если исходное сообщение удалено
+	AllowSendingWithoutReply bool                  `json:"allow_sending_without_reply,omitempty"`
+	ReplyMarkup              *inlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type inlineKeyboardMarkup struct {
+	InlineKeyboard [][]inlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type inlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type pinChatMessageRequest struct {
+	ChatID    string `json:"chat_id"`
+	MessageID int    `json:"message_id"`
+}
+
+type message struct {
+	MessageID int `json:"message_id"`
+}
+
+type apiResponse struct {
+	OK          bool                `json:"ok"`
+	Description string              `json:"description"`
+	ErrorCode   int                 `json:"error_code"`
+	Parameters  *responseParameters `json:"parameters"`
+	Result      json.RawMessage     `json:"result"`
+}
+
+type responseParameters struct {
+	// RetryAfter - через сколько секунд можно повторить запрос после ошибки 429
+	RetryAfter int `json:"retry_after"`
+}
+
+// apiError - запрос отклонён Bot API
+type apiError struct {
+	Code        int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("telegram api error %d: %s", e.Code, e.Description)
+}
+
+// permanent сообщает, что повтор запроса не поможет: бота удалили из чата, чат не найден,
+// сообщение некорректно. 429 (слишком много запросов) повторяется через retry_after.
+func (e *apiError) permanent() bool {
+	return e.Code >= 400 && e.Code < 500 && e.Code != http.StatusTooManyRequests
+}
+
+// SendMessage отправляет уведомление в чат с повторными попытками
+func (c *Client) SendMessage(ctx context.Context, n domain.Notification) error {
+	req := sendMessageRequest{
+		ChatID:                n.ChatID,
+		Text:                  n.Message,
+		ParseMode:             n.ParseMode,
+		DisableWebPagePreview: true,
+		DisableNotification:   n.DisableNotification,
+		MessageThreadID:       n.MessageThreadID,
+	}
+	if n.ReplyToMessageID != 0 {
+		req.ReplyToMessageID = n.ReplyToMessageID
+		req.AllowSendingWithoutReply = true
+	}
+	if len(n.Buttons) > 0 {
+		row := make([]inlineKeyboardButton, 0, len(n.Buttons))
+		for _, b := range n.Buttons {
+			row = append(row, inlineKeyboardButton{Text: b.Text, CallbackData: b.Data})
+		}
+		req.ReplyMarkup = &inlineKeyboardMarkup{InlineKeyboard: [][]inlineKeyboardButton{row}}
+	}
+
+	var (
+		lastErr error
+		apiErr  *apiError
+		attempt int
+	)
+	for ; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			// Экспоненциальная задержка между попытками: 1s, 2s, 4s...; после 429 - сколько просит Telegram
+			backoff := time.Second << (attempt - 1)
+			if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+				backoff = apiErr.RetryAfter
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			log.Printf("🔁 Повторная отправка в чат %s (попытка %d/%d)", n.ChatID, attempt, c.maxRetries)
+		}
+
+		var sent message
+		lastErr = c.call(ctx, "sendMessage", req, &sent)
+		if lastErr == nil {
+			if n.Sent != nil {
+				n.Sent(sent.MessageID)
+			}
+			if n.Pin {
+				c.pin(ctx, n.ChatID, sent.MessageID)
+			}
+			return nil
+		}
+
+		if errors.As(lastErr, &apiErr) && apiErr.permanent() {
+			attempt++
+			break
+		}
+	}
+
+	return &domain.DeliveryError{
+		ChatID:    n.ChatID,
+		Attempts:  attempt,
+		Err:       lastErr,
+		Permanent: errors.As(lastErr, &apiErr) && apiErr.permanent(),
+	}
+}
+
+// pin закрепляет сообщение. Ошибка не считается ошибкой доставки:
+// сообщение уже отправлено, и повторная отправка привела бы к дублю.
+func (c *Client) pin(ctx context.Context, chatID string, messageID int) {
+	req := pinChatMessageRequest{ChatID: chatID, MessageID: messageID}
+	if err := c.call(ctx, "pinChatMessage", req, nil); err != nil {
+		log.Printf("⚠️ Не удалось закрепить сообщение %d в чате %s: %v", messageID, chatID, err)
+	}
+}
+
+func (c *Client) methodURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", c.apiURL, c.token(), method)
+}
+
+// call выполняет запрос к методу Bot API и декодирует поле result в out
+func (c *Client) call(ctx context.Context, method string, payload, out any) error {
+	return c.do(ctx, c.httpClient, method, payload, out)
+}
+
+func (c *Client) do(ctx context.Context, httpClient *http.Client, method string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.methodURL(method), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		// URL запроса содержит токен бота - не допускаем его попадания в логи
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request %s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var apiResp apiResponse
+	if err := json.Unmarshal(data, &apiResp); err != nil {
+		return fmt.Errorf("failed to decode response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if !apiResp.OK {
+		apiErr := &apiError{Code: apiResp.ErrorCode, Description: apiResp.Description}
+		if apiResp.Parameters != nil {
+			apiErr.RetryAfter = time.Duration(apiResp.Parameters.RetryAfter) * time.Second
+		}
+		return apiErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(apiResp.Result, out); err != nil {
+			return fmt.Errorf("failed to decode result: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// allowedUpdates - типы обновлений, которые разбирает бот
+var allowedUpdates = []string{"message", "callback_query"}
+
+type getUpdatesRequest struct {
+	Offset         int      `json:"offset,omitempty"`
+	Timeout        int      `json:"timeout"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+type answerCallbackQueryRequest struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+type getChatMemberRequest struct {
+	ChatID string `json:"chat_id"`
+	UserID int64  `json:"user_id"`
+}
+
+type chatMember struct {
+	Status string `json:"status"`
+}
+
+// apiUpdate - обновление в формате Bot API
+type apiUpdate struct {
+	UpdateID      int            `json:"update_id"`
+	Message       *apiMessage    `json:"message"`
+	CallbackQuery *callbackQuery `json:"callback_query"`
+}
+
+type apiMessage struct {
+	MessageID       int    `json:"message_id"`
+	MessageThreadID int    `json:"message_thread_id"`
+	From            *user  `json:"from"`
+	Chat            chat   `json:"chat"`
+	Text            string `json:"text"`
+}
+
+type callbackQuery struct {
+	ID      string      `json:"id"`
+	From    user        `json:"from"`
+	Message *apiMessage `json:"message"`
+	Data    string      `json:"data"`
+}
+
+type user struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type chat struct {
+	ID int64 `json:"id"`
+}
+
+// GetUpdates ждёт новые обновления до timeout (long polling) и возвращает их.
+// offset - номер первого нужного обновления; более ранние Telegram считает подтверждёнными.
+func (c *Client) GetUpdates(ctx context.Context, offset int, timeout time.Duration) ([]domain.Update, error) {
+	// Запрос держится открытым до timeout, поэтому обычный таймаут клиента добавляется сверху
+	ctx, cancel := context.WithTimeout(ctx, timeout+c.timeout)
+	defer cancel()
+
+	req := getUpdatesRequest{
+		Offset:         offset,
+		Timeout:        int(timeout / time.Second),
+		AllowedUpdates: allowedUpdates,
+	}
+
+	var updates []apiUpdate
+	if err := c.do(ctx, c.pollClient, "getUpdates", req, &updates); err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.Update, 0, len(updates))
+	for _, u := range updates {
+		result = append(result, u.toDomain())
+	}
+	return result, nil
+}
+
+// GetMe возвращает имя пользователя бота (без @)
+func (c *Client) GetMe(ctx context.Context) (string, error) {
+	var me user
+	if err := c.call(ctx, "getMe", struct{}{}, &me); err != nil {
+		return "", err
+	}
+	return me.Username, nil
+}
+
+// AnswerCallbackQuery подтверждает нажатие кнопки; text показывается пользователю всплывающим уведомлением
+func (c *Client) AnswerCallbackQuery(ctx context.Context, id, text string) error {
+	req := answerCallbackQueryRequest{CallbackQueryID: id, Text: text}
+	if err := c.call(ctx, "answerCallbackQuery", req, nil); err != nil {
+		return fmt.Errorf("failed to answer callback query: %w", err)
+	}
+	return nil
+}
+
+// ChatMemberStatus возвращает статус пользователя в чате: creator, administrator, member, restricted, left или kicked
+func (c *Client) ChatMemberStatus(ctx context.Context, chatID string, userID int64) (string, error) {
+	var member chatMember
+	if err := c.call(ctx, "getChatMember", getChatMemberRequest{ChatID: chatID, UserID: userID}, &member); err != nil {
+		return "", fmt.Errorf("failed to get chat member: %w", err)
+	}
+	return member.Status, nil
+}
+
+// toDomain переводит обновление в доменный формат
+func (u apiUpdate) toDomain() domain.Update {
+	update := domain.Update{ID: u.UpdateID}
+
+	if m := u.Message; m != nil {
+		update.Message = &domain.IncomingMessage{
+			ID:       m.MessageID,
+			ChatID:   strconv.FormatInt(m.Chat.ID, 10),
+			ThreadID: m.MessageThreadID,
+			Text:     m.Text,
+		}
+		if m.From != nil {
+			update.Message.UserID = m.From.ID
+			update.Message.Username = m.From.Username
+		}
+	}
+
+	if q := u.CallbackQuery; q != nil {
+		update.Callback = &domain.CallbackQuery{
+			ID:       q.ID,
+			UserID:   q.From.ID,
+			Username: q.From.Username,
+			Data:     q.Data,
+		}
+		// Сообщение с кнопкой может быть недоступно, если оно слишком старое
+		if q.Message != nil {
+			update.Callback.ChatID = strconv.FormatInt(q.Message.Chat.ID, 10)
+			update.Callback.MessageID = q.Message.MessageID
+			update.Callback.ThreadID = q.Message.MessageThreadID
+		}
+	}
+
+	return update
+}
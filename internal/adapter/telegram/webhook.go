@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+type setWebhookRequest struct {
+	URL            string   `json:"url"`
+	SecretToken    string   `json:"secret_token"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+// SetWebhook регистрирует адрес, на который Telegram отправляет обновления.
+// secret приходит в заголовке X-Telegram-Bot-Api-Secret-Token каждого запроса.
+func (c *Client) SetWebhook(ctx context.Context, url, secret string) error {
+	req := setWebhookRequest{URL: url, SecretToken: secret, AllowedUpdates: allowedUpdates}
+	if err := c.call(ctx, "setWebhook", req, nil); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook отключает вебхук; обновления снова можно получать через getUpdates
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	if err := c.call(ctx, "deleteWebhook", struct{}{}, nil); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// DecodeUpdate разбирает обновление из тела запроса вебхука
+func DecodeUpdate(data []byte) (domain.Update, error) {
+	var u apiUpdate
+	if err := json.Unmarshal(data, &u); err != nil {
+		return domain.Update{}, fmt.Errorf("failed to decode update: %w", err)
+	}
+	return u.toDomain(), nil
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+)
+
+type AdminHandler struct {
+	unknownProjects *usecase.UnknownProjects
+}
+
+func NewAdminHandler(unknownProjects *usecase.UnknownProjects) *AdminHandler {
+	return &AdminHandler{
+		unknownProjects: unknownProjects,
+	}
+}
+
+// UnknownProjects возвращает проекты, от которых приходили хуки, но которых нет в конфигурации
+func (h *AdminHandler) UnknownProjects(w http.ResponseWriter, r *http.Request) {
+	projects := h.unknownProjects.List()
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"count":    len(projects),
+		"projects": projects,
+	})
+}
+
+// ForgetUnknownProject убирает проект из списка неизвестных.
+// Инстанс GitLab задаётся параметром ?instance= (по умолчанию - основной).
+func (h *AdminHandler) ForgetUnknownProject(w http.ResponseWriter, r *http.Request) {
+	key := domain.ProjectKey{
+		Instance:  r.URL.Query().Get("instance"),
+		ProjectID: chi.URLParam(r, "projectID"),
+	}
+	if key.Instance == "" {
+		key.Instance = domain.DefaultInstance
+	}
+
+	if !h.unknownProjects.Forget(key) {
+		response.Error(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+)
+
+// ChatSettingsHandler - административное API настроек чатов
+type ChatSettingsHandler struct {
+	chats *usecase.ChatSettings
+}
+
+func NewChatSettingsHandler(chats *usecase.ChatSettings) *ChatSettingsHandler {
+	return &ChatSettingsHandler{
+		chats: chats,
+	}
+}
+
+// List возвращает сохранённые настройки всех чатов
+func (h *ChatSettingsHandler) List(w http.ResponseWriter, r *http.Request) {
+	list := h.chats.List()
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"count": len(list),
+		"chats": list,
+	})
+}
+
+func (h *ChatSettingsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	settings, ok := h.chats.Lookup(chi.URLParam(r, "chatID"))
+	if !ok {
+		response.Error(w, http.StatusNotFound, domain.ErrChatSettingsNotFound.Error())
+		return
+	}
+
+	response.JSON(w, http.StatusOK, settings)
+}
+
+// Put заменяет настройки чата целиком; chat_id берётся из адреса запроса
+func (h *ChatSettingsHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var settings domain.ChatSettings
+	if !decodeJSON(w, r, &settings) {
+		return
+	}
+
+	chatID := chi.URLParam(r, "chatID")
+	if settings.ChatID != "" && settings.ChatID != chatID {
+		response.Error(w, http.StatusBadRequest, "chat_id in body does not match the request path")
+		return
+	}
+	settings.ChatID = chatID
+
+	settings, err := h.chats.Put(r.Context(), settings)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, settings)
+}
+
+// Mute заглушает чат: тело {"for": "2h"} или {"until": "2026-10-20T09:00:00Z"}
+func (h *ChatSettingsHandler) Mute(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		For   string    `json:"for"`
+		Until time.Time `json:"until"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	until := req.Until
+	if req.For != "" {
+		d, err := time.ParseDuration(req.For)
+		if err != nil || d <= 0 {
+			response.Error(w, http.StatusBadRequest, "for must be a positive duration like 30m or 2h")
+			return
+		}
+		until = time.Now().Add(d)
+	}
+	if until.IsZero() || !until.After(time.Now()) {
+		response.Error(w, http.StatusBadRequest, "either for or a future until is required")
+		return
+	}
+
+	h.mute(w, r, until)
+}
+
+func (h *ChatSettingsHandler) Unmute(w http.ResponseWriter, r *http.Request) {
+	h.mute(w, r, time.Time{})
+}
+
+func (h *ChatSettingsHandler) mute(w http.ResponseWriter, r *http.Request, until time.Time) {
+	settings, err := h.chats.Mute(r.Context(), chi.URLParam(r, "chatID"), until)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, settings)
+}
+
+// Delete удаляет настройки чата: дальше действуют значения по умолчанию
+func (h *ChatSettingsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.chats.Delete(r.Context(), chi.URLParam(r, "chatID")); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatSettingsHandler) error(w http.ResponseWriter, err error) {
+	var validation *usecase.ValidationError
+
+	switch {
+	case errors.Is(err, domain.ErrChatSettingsNotFound):
+		response.Error(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &validation):
+		response.Error(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		log.Printf("❌ Ошибка хранилища настроек чатов: %v", err)
+		response.Error(w, http.StatusInternalServerError, "chat settings store error")
+	}
+}
+
+// decodeJSON строго разбирает тело запроса в v; при ошибке отвечает 400
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid body")
+		return false
+	}
+	defer r.Body.Close()
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+)
+
+const (
+	defaultDeadLettersLimit = 100
+	maxDeadLettersLimit     = 1000
+	// deadLetterPreviewLength - длина текста сообщения в списке; полностью текст выводится при просмотре
+	deadLetterPreviewLength = 200
+)
+
+// DeadLettersHandler - административное API очереди недоставленных сообщений
+type DeadLettersHandler struct {
+	deadLetters *usecase.DeadLetters
+}
+
+func NewDeadLettersHandler(deadLetters *usecase.DeadLetters) *DeadLettersHandler {
+	return &DeadLettersHandler{
+		deadLetters: deadLetters,
+	}
+}
+
+type deadLetterView struct {
+	ID                  int64     `json:"id"`
+	ChatID              string    `json:"chat_id"`
+	Message             string    `json:"message"`
+	ParseMode           string    `json:"parse_mode,omitempty"`
+	Priority            string    `json:"priority"`
+	DisableNotification bool      `json:"disable_notification,omitempty"`
+	Pin                 bool      `json:"pin,omitempty"`
+	Error               string    `json:"error"`
+	Attempts            int       `json:"attempts"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+func newDeadLetterView(letter domain.DeadLetter) deadLetterView {
+	n := letter.Notification
+	return deadLetterView{
+		ID:                  letter.ID,
+		ChatID:              n.ChatID,
+		Message:             n.Message,
+		ParseMode:           n.ParseMode,
+		Priority:            n.Priority.String(),
+		DisableNotification: n.DisableNotification,
+		Pin:                 n.Pin,
+		Error:               letter.Error,
+		Attempts:            letter.Attempts,
+		CreatedAt:           letter.CreatedAt,
+	}
+}
+
+// List возвращает недоставленные сообщения, начиная с новых (?chat_id=, ?limit=)
+func (h *DeadLettersHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := defaultDeadLettersLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			response.Error(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxDeadLettersLimit)
+	}
+
+	letters, err := h.deadLetters.List(r.Context(), r.URL.Query().Get("chat_id"), limit)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	views := make([]deadLetterView, 0, len(letters))
+	for _, letter := range letters {
+		view := newDeadLetterView(letter)
+		if runes := []rune(view.Message); len(runes) > deadLetterPreviewLength {
+			view.Message = string(runes[:deadLetterPreviewLength]) + "…"
+		}
+		views = append(views, view)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"count":        len(views),
+		"dead_letters": views,
+	})
+}
+
+func (h *DeadLettersHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	letter, err := h.deadLetters.Get(r.Context(), id)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newDeadLetterView(letter))
+}
+
+// Retry отправляет сообщение повторно; тело {"chat_id": "..."} (необязательно) меняет чат
+func (h *DeadLettersHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ChatID string `json:"chat_id"`
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	defer r.Body.Close()
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+	}
+
+	letter, err := h.deadLetters.Retry(r.Context(), id, req.ChatID)
+	if errors.Is(err, usecase.ErrQueueFull) {
+		response.Error(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, map[string]any{
+		"status":  "queued",
+		"id":      letter.ID,
+		"chat_id": letter.Notification.ChatID,
+	})
+}
+
+func (h *DeadLettersHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := deadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetters.Delete(r.Context(), id); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Purge удаляет все недоставленные сообщения или только сообщения чата ?chat_id=
+func (h *DeadLettersHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	n, err := h.deadLetters.Purge(r.Context(), r.URL.Query().Get("chat_id"))
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]int{"purged": n})
+}
+
+func (h *DeadLettersHandler) error(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrDeadLetterNotFound) {
+		response.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	log.Printf("❌ Ошибка очереди недоставленных сообщений: %v", err)
+	response.Error(w, http.StatusInternalServerError, "dead letter store error")
+}
+
+func deadLetterID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid dead letter id")
+		return 0, false
+	}
+	return id, true
+}
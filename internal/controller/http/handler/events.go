@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+)
+
+const (
+	defaultEventsLimit = 50
+	maxEventsLimit     = 500
+)
+
+// EventsHandler - административное API истории событий
+type EventsHandler struct {
+	history *usecase.EventHistory
+}
+
+func NewEventsHandler(history *usecase.EventHistory) *EventsHandler {
+	return &EventsHandler{
+		history: history,
+	}
+}
+
+// List возвращает события, начиная с новых. Фильтры: ?instance=, ?project= (ID или имя), ?ref=,
+// ?author=, ?kind=, ?outcome=, ?since= и ?until= (RFC 3339). Следующая страница - ?cursor=<next_cursor>.
+func (h *EventsHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := domain.EventFilter{
+		Instance: query.Get("instance"),
+		Project:  query.Get("project"),
+		Ref:      query.Get("ref"),
+		Author:   query.Get("author"),
+		Kind:     domain.EventKind(query.Get("kind")),
+		Outcome:  domain.EventOutcome(query.Get("outcome")),
+		Limit:    defaultEventsLimit,
+	}
+
+	var err error
+	if filter.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		response.Error(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+		return
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		response.Error(w, http.StatusBadRequest, "until must be an RFC 3339 time")
+		return
+	}
+	if value := query.Get("cursor"); value != "" {
+		if filter.Before, err = strconv.ParseInt(value, 10, 64); err != nil || filter.Before <= 0 {
+			response.Error(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			response.Error(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		filter.Limit = min(n, maxEventsLimit)
+	}
+
+	events, err := h.history.Query(r.Context(), filter)
+	if err != nil {
+		log.Printf("❌ Ошибка чтения истории событий: %v", err)
+		response.Error(w, http.StatusInternalServerError, "event history error")
+		return
+	}
+
+	result := map[string]any{
+		"count":  len(events),
+		"events": events,
+	}
+	// Полная страница - возможно, есть следующая
+	if len(events) == filter.Limit {
+		result["next_cursor"] = strconv.FormatInt(events[len(events)-1].ID, 10)
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+	"github.com/sensetion/tgGitlabBot/pkg/config"
+)
+
+// RepositoriesHandler - административное API реестра репозиториев.
+// Запись определяется проектом, чатом и инстансом GitLab (?instance=, по умолчанию - основной).
+type RepositoriesHandler struct {
+	repositories *usecase.RepositoryService
+}
+
+func NewRepositoriesHandler(repositories *usecase.RepositoryService) *RepositoriesHandler {
+	return &RepositoriesHandler{
+		repositories: repositories,
+	}
+}
+
+// List возвращает все записи и маршрут по умолчанию; секреты вебхука скрыты
+func (h *RepositoriesHandler) List(w http.ResponseWriter, r *http.Request) {
+	set, err := h.repositories.List(r.Context())
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"count":         len(set.Repositories),
+		"repositories":  set.Repositories,
+		"default_route": set.DefaultRoute,
+	})
+}
+
+func (h *RepositoriesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	repo, err := h.repositories.Get(r.Context(), routeKey(r))
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, repo)
+}
+
+func (h *RepositoriesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	repo, ok := decodeBody(w, r, config.DecodeRepository)
+	if !ok {
+		return
+	}
+
+	if err := h.repositories.Create(r.Context(), repo); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, repo)
+}
+
+// Update заменяет запись целиком (скрытые секреты *** сохраняют текущие значения).
+// Не указанные в теле id, telegram_channel_id и instance
+// берутся из адреса запроса.
+func (h *RepositoriesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	repo, ok := decodeBody(w, r, config.DecodeRepository)
+	if !ok {
+		return
+	}
+
+	key := routeKey(r)
+	if repo.ID == "" {
+		repo.ID = key.ProjectID
+	}
+	if repo.TelegramChatID == "" {
+		repo.TelegramChatID = key.ChatID
+	}
+	if repo.Instance == "" && key.Instance != domain.DefaultInstance {
+		repo.Instance = key.Instance
+	}
+
+	if err := h.repositories.Update(r.Context(), key, repo); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, repo)
+}
+
+func (h *RepositoriesHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	h.setEnabled(w, r, true)
+}
+
+func (h *RepositoriesHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	h.setEnabled(w, r, false)
+}
+
+func (h *RepositoriesHandler) setEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	repo, err := h.repositories.SetEnabled(r.Context(), routeKey(r), enabled)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, repo)
+}
+
+func (h *RepositoriesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.repositories.Delete(r.Context(), routeKey(r)); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RepositoriesHandler) DefaultRoute(w http.ResponseWriter, r *http.Request) {
+	set, err := h.repositories.List(r.Context())
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+	if set.DefaultRoute == nil {
+		response.Error(w, http.StatusNotFound, "default route is not configured")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, set.DefaultRoute)
+}
+
+func (h *RepositoriesHandler) SetDefaultRoute(w http.ResponseWriter, r *http.Request) {
+	route, ok := decodeBody(w, r, config.DecodeDefaultRoute)
+	if !ok {
+		return
+	}
+
+	if err := h.repositories.SetDefaultRoute(r.Context(), &route); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, route)
+}
+
+func (h *RepositoriesHandler) DeleteDefaultRoute(w http.ResponseWriter, r *http.Request) {
+	if err := h.repositories.SetDefaultRoute(r.Context(), nil); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RepositoriesHandler) error(w http.ResponseWriter, err error) {
+	var (
+		problems   *config.RepositoriesError
+		validation *usecase.ValidationError
+	)
+
+	switch {
+	case errors.Is(err, domain.ErrRepositoryNotFound):
+		response.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrRepositoryExists):
+		response.Error(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrRegistryReadOnly):
+		response.Error(w, http.StatusConflict, "repositories are loaded from repositories.json; set registry.backend to sqlite to manage them via API")
+	case errors.As(err, &problems):
+		response.JSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":    "invalid repository configuration",
+			"problems": problems.Problems,
+		})
+	case errors.As(err, &validation):
+		response.Error(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		log.Printf("❌ Ошибка реестра репозиториев: %v", err)
+		response.Error(w, http.StatusInternalServerError, "repository registry error")
+	}
+}
+
+// routeKey собирает ключ записи из адреса запроса
+func routeKey(r *http.Request) domain.RouteKey {
+	key := domain.RouteKey{
+		Instance:  r.URL.Query().Get("instance"),
+		ProjectID: chi.URLParam(r, "projectID"),
+		ChatID:    chi.URLParam(r, "chatID"),
+	}
+	if key.Instance == "" {
+		key.Instance = domain.DefaultInstance
+	}
+	return key
+}
+
+// decodeBody читает тело запроса и строго разбирает его; при ошибке отвечает 400 или 422
+func decodeBody(w http.ResponseWriter, r *http.Request, decode func([]byte) (domain.Repository, error)) (domain.Repository, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid body")
+		return domain.Repository{}, false
+	}
+	defer r.Body.Close()
+
+	repo, err := decode(body)
+	var problems *config.RepositoriesError
+	if errors.As(err, &problems) {
+		response.JSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":    "invalid repository",
+			"problems": problems.Problems,
+		})
+		return domain.Repository{}, false
+	}
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return domain.Repository{}, false
+	}
+
+	return repo, true
+}
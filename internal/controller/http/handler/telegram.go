@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/telegram"
+	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+)
+
+const (
+	// maxTelegramUpdateSize - ограничение размера тела обновления: текст сообщения не длиннее 4096 символов
+	maxTelegramUpdateSize = 1 << 20
+	// recentUpdateIDs - сколько последних update_id запоминается для отсева повторных доставок
+	recentUpdateIDs = 1000
+)
+
+// TelegramHandler принимает обновления Telegram в режиме вебхука
+type TelegramHandler struct {
+	commands *usecase.CommandRouter
+
+	mu sync.Mutex
+	// seen - недавно принятые update_id, order - порядок их вытеснения
+	seen  map[int]struct{}
+	order []int
+}
+
+func NewTelegramHandler(commands *usecase.CommandRouter) *TelegramHandler {
+	return &TelegramHandler{
+		commands: commands,
+		seen:     make(map[int]struct{}),
+	}
+}
+
+// HandleUpdate передаёт обновление маршрутизатору команд. Ответ 200 отправляется и при
+// ошибке команды: иначе Telegram повторял бы то же обновление. Повторная доставка того же
+// update_id (Telegram повторяет запрос, если не дождался ответа) не выполняет команду ещё раз.
+func (h *TelegramHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxTelegramUpdateSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Error(w, http.StatusRequestEntityTooLarge, "body too large")
+			return
+		}
+		response.Error(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	defer r.Body.Close()
+
+	update, err := telegram.DecodeUpdate(body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid update")
+		return
+	}
+
+	if !h.claim(update.ID) {
+		log.Printf("🔁 Повторное обновление Telegram %d пропущено", update.ID)
+		response.JSON(w, http.StatusOK, map[string]string{"status": "duplicate"})
+		return
+	}
+
+	// Ответ на команду не должен обрываться таймаутом запроса: время ответа ограничено
+	// таймаутом клиента Telegram, а остановка сервера дожидается завершения обработчика
+	h.commands.Dispatch(context.WithoutCancel(r.Context()), update)
+
+	response.JSON(w, http.StatusOK, map[string]string{"status": "processed"})
+}
+
+// claim запоминает update_id и возвращает false, если обновление уже принималось.
+// Номер запоминается до выполнения команды, чтобы повтор, пришедший во время её
+// выполнения, тоже был отброшен.
+func (h *TelegramHandler) claim(id int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.seen[id]; ok {
+		return false
+	}
+	h.seen[id] = struct{}{}
+	h.order = append(h.order, id)
+
+	if len(h.order) > recentUpdateIDs {
+		delete(h.seen, h.order[0])
+		h.order = h.order[1:]
+	}
+	return true
+}
@@ -1,22 +1,33 @@
 package handler
 
 import (
+	"errors"
 	"io"
 	"log"
 	"net/http"
 
 	"github.com/sensetion/tgGitlabBot/internal/adapter/gitlab"
+	chimw "github.com/sensetion/tgGitlabBot/internal/controller/http/middleware"
 	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
 	"github.com/sensetion/tgGitlabBot/pkg/logger"
 )
 
 type WebhookHandler struct {
-	parser *gitlab.Parser
+	parser   *gitlab.Parser
+	notifier *usecase.Notifier
+	// outbox - nil, если события доставляются сразу, без сохранения
+	outbox *usecase.OutboxDispatcher
+	// idempotency - nil, если проверка повторов отключена
+	idempotency *usecase.Idempotency
 }
 
-func NewWebhookHandler() *WebhookHandler {
+func NewWebhookHandler(notifier *usecase.Notifier, outbox *usecase.OutboxDispatcher, idempotency *usecase.Idempotency) *WebhookHandler {
 	return &WebhookHandler{
-		parser: gitlab.NewParser(),
+		parser:      gitlab.NewParser(),
+		notifier:    notifier,
+		outbox:      outbox,
+		idempotency: idempotency,
 	}
 }
 
@@ -33,15 +44,112 @@ func (h *WebhookHandler) HandleGitLabPush(w http.ResponseWriter, r *http.Request
 	}
 	defer r.Body.Close()
 
-	event, err := h.parser.ParsePushEvent(body)
+	event, err := h.parser.Parse(body)
+	if errors.Is(err, gitlab.ErrUnsupportedEvent) {
+		log.Printf("⏭️ %v", err)
+		response.JSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Parse error: %v", err)
 		response.Error(w, http.StatusBadRequest, "invalid payload")
 		return
 	}
 
-	log.Println("🚀 GitLab Push Event Received:")
+	event.Instance = chimw.InstanceFromContext(r.Context())
+
+	key := idempotencyKey(r, event.Instance)
+	if h.idempotency != nil {
+		switch h.idempotency.Begin(key) {
+		case usecase.DeliveryDuplicate:
+			log.Printf("🔁 Повтор вебхука %s, уже обработан", key)
+			response.JSON(w, http.StatusOK, map[string]string{"status": "duplicate"})
+			return
+		case usecase.DeliveryInProgress:
+			// Исход первой доставки ещё неизвестен: GitLab повторит запрос позже
+			log.Printf("🔁 Повтор вебхука %s, первая доставка ещё обрабатывается", key)
+			response.Error(w, http.StatusConflict, "webhook with this key is being processed")
+			return
+		}
+	}
+
+	log.Printf("🚀 GitLab %s Event Received:", event.Kind)
 	logger.PrettyStructurePrint("Event :", event)
 
+	if h.outbox != nil {
+		// Хук подтверждается только после сохранения события, доставка идёт в фоне
+		if err := h.outbox.Enqueue(r.Context(), event); err != nil {
+			if h.idempotency != nil {
+				h.idempotency.Release(key)
+			}
+			log.Printf("❌ %v", err)
+			response.Error(w, http.StatusServiceUnavailable, "failed to accept event")
+			return
+		}
+		if h.idempotency != nil {
+			h.idempotency.Complete(key)
+		}
+
+		response.JSON(w, http.StatusAccepted, map[string]string{"status": "accepted"})
+		return
+	}
+
+	sent, err := h.notifier.Notify(r.Context(), event)
+	if err != nil && sent == 0 {
+		if h.idempotency != nil {
+			h.idempotency.Release(key)
+		}
+		log.Printf("❌ Notification error: %v", err)
+		response.Error(w, http.StatusServiceUnavailable, "failed to accept notification")
+		return
+	}
+	if err != nil {
+		// Часть чатов уже получила уведомление: повтор хука GitLab привёл бы к дублям
+		log.Printf("⚠️ Уведомление принято не для всех чатов: %v", err)
+	}
+	if h.idempotency != nil {
+		h.idempotency.Complete(key)
+	}
+
+	if sent == 0 {
+		response.JSON(w, http.StatusOK, map[string]string{"status": "skipped"})
+		return
+	}
+
 	response.JSON(w, http.StatusOK, map[string]string{"status": "processed"})
 }
+
+// idempotencyKey возвращает ключ повторной доставки: X-Gitlab-Event-UUID, иначе Idempotency-Key.
+// Ключ включает инстанс GitLab, чтобы UUID разных инстансов не пересекались.
+func idempotencyKey(r *http.Request, instance string) string {
+	key := r.Header.Get("X-Gitlab-Event-UUID")
+	if key == "" {
+		key = r.Header.Get("Idempotency-Key")
+	}
+	if key == "" {
+		return ""
+	}
+	return instance + "/" + key
+}
+
+// DryRun показывает, по каким маршрутам было бы доставлено событие, ничего не отправляя
+func (h *WebhookHandler) DryRun(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	defer r.Body.Close()
+
+	event, err := h.parser.Parse(body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	event.Instance = chimw.InstanceFromContext(r.Context())
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"event":  event.Env()["event"],
+		"routes": h.notifier.Explain(event),
+	})
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+)
+
+// AdminAuth проверяет токен административного API в заголовке Authorization: Bearer <token>
+func AdminAuth(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || provided == "" {
+				response.Error(w, http.StatusUnauthorized, "missing admin token")
+				return
+			}
+
+			// Безопасное сравнение строк (защита от timing attacks)
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				response.Error(w, http.StatusUnauthorized, "invalid admin token")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
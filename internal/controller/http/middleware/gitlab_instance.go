@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// GitLabInstance - инстанс GitLab, от которого принимаются вебхуки
+type GitLabInstance struct {
+	Name    string
+	BaseURL string
+	// Secret возвращает актуальный секрет вебхука инстанса
+	Secret func() string
+}
+
+// GitLabInstances определяет, какой инстанс GitLab прислал запрос
+type GitLabInstances struct {
+	byName map[string]GitLabInstance
+	byURL  map[string]string
+}
+
+func NewGitLabInstances(instances []GitLabInstance) *GitLabInstances {
+	i := &GitLabInstances{
+		byName: make(map[string]GitLabInstance, len(instances)),
+		byURL:  make(map[string]string, len(instances)),
+	}
+
+	for _, instance := range instances {
+		i.byName[instance.Name] = instance
+		if instance.BaseURL != "" {
+			i.byURL[normalizeInstanceURL(instance.BaseURL)] = instance.Name
+		}
+	}
+
+	return i
+}
+
+// resolve выбирает инстанс по параметру пути {instance}, затем по заголовку X-Gitlab-Instance,
+// который GitLab заполняет адресом инстанса. Если заголовок не совпал ни с одним base_url,
+// используется инстанс по умолчанию.
+func (i *GitLabInstances) resolve(r *http.Request) (GitLabInstance, bool) {
+	if name := chi.URLParam(r, "instance"); name != "" {
+		instance, ok := i.byName[name]
+		return instance, ok
+	}
+
+	if header := r.Header.Get("X-Gitlab-Instance"); header != "" {
+		if name, ok := i.byURL[normalizeInstanceURL(header)]; ok {
+			return i.byName[name], true
+		}
+	}
+
+	instance, ok := i.byName[domain.DefaultInstance]
+	return instance, ok
+}
+
+// normalizeInstanceURL приводит адрес к виду scheme://host[/path] без завершающего слеша
+func normalizeInstanceURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(strings.ToLower(raw), "/")
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + strings.TrimSuffix(u.Path, "/")
+}
+
+type instanceKey struct{}
+
+// InstanceFromContext возвращает имя инстанса GitLab, определённое WebhookAuth
+func InstanceFromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(instanceKey{}).(string); ok {
+		return name
+	}
+	return domain.DefaultInstance
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
+)
+
+// TelegramAuth проверяет секрет вебхука Telegram в заголовке X-Telegram-Bot-Api-Secret-Token
+// (задаётся при setWebhook)
+func TelegramAuth(secret string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+			if provided == "" {
+				response.Error(w, http.StatusUnauthorized, "missing telegram secret token")
+				return
+			}
+
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) != 1 {
+				response.Error(w, http.StatusUnauthorized, "invalid telegram secret token")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
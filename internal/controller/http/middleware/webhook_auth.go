@@ -1,13 +1,33 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"crypto/subtle"
+	"errors"
+	"io"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/gitlab"
 	"github.com/sensetion/tgGitlabBot/internal/controller/http/response"
 )
 
-func WebhookAuth(secret string) func(next http.Handler) http.Handler {
+// maxWebhookBodySize - ограничение размера тела хука (как у самого GitLab): тело читается
+// в память до проверки токена, поэтому без ограничения его размер задавал бы кто угодно
+const maxWebhookBodySize = 25 << 20
+
+// ProjectSecrets возвращает действующие секреты вебхука проекта инстанса GitLab.
+// ok == false, если у проекта нет собственных секретов.
+type ProjectSecrets func(instance, projectID string) (secrets []string, ok bool)
+
+// WebhookAuth проверяет X-Gitlab-Token. Сначала определяется инстанс GitLab (см. GitLabInstances),
+// затем проект - по параметру пути {projectID} или по телу хука. Если у проекта есть собственные
+// секреты, принимаются только они, иначе - секрет инстанса. Имя инстанса передаётся обработчику
+// через контекст (InstanceFromContext).
+func WebhookAuth(instances *GitLabInstances, project ProjectSecrets) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			token := r.Header.Get("X-Gitlab-Token")
@@ -17,13 +37,68 @@ func WebhookAuth(secret string) func(next http.Handler) http.Handler {
 				return
 			}
 
-			// Безопасное сравнение строк (защита от timing attacks)
-			if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			instance, ok := instances.resolve(r)
+			if !ok {
+				response.Error(w, http.StatusUnauthorized, "unknown gitlab instance")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					response.Error(w, http.StatusRequestEntityTooLarge, "body too large")
+					return
+				}
+				response.Error(w, http.StatusBadRequest, "invalid body")
+				return
+			}
+			r.Body.Close()
+			// Тело уже прочитано: обработчику передаётся его копия
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			projectID, err := gitlab.ProjectID(body)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "invalid payload")
+				return
+			}
+
+			// Проект из пути должен совпадать с проектом из тела, иначе секрет одного проекта
+			// позволил бы отправлять события от имени другого
+			if fromPath := chi.URLParam(r, "projectID"); fromPath != "" && fromPath != projectID {
+				response.Error(w, http.StatusUnauthorized, "project mismatch")
+				return
+			}
+
+			secrets, ok := project(instance.Name, projectID)
+			if !ok {
+				secrets = []string{instance.Secret()}
+			}
+
+			if !matchAny(token, secrets) {
 				response.Error(w, http.StatusUnauthorized, "invalid webhook token")
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), instanceKey{}, instance.Name)))
 		})
 	}
 }
+
+// matchAny сравнивает токен со всеми секретами за время, не зависящее от того,
+// какой секрет совпал и совпал ли вообще: сравниваются хеши одинаковой длины,
+// и перебор не прерывается досрочно
+func matchAny(token string, secrets []string) bool {
+	provided := sha256.Sum256([]byte(token))
+
+	matched := 0
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		expected := sha256.Sum256([]byte(secret))
+		matched |= subtle.ConstantTimeCompare(provided[:], expected[:])
+	}
+
+	return matched == 1
+}
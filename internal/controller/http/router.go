@@ -1,6 +1,7 @@
 package http
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -8,21 +9,46 @@ import (
 	"github.com/go-chi/render"
 	"github.com/sensetion/tgGitlabBot/internal/controller/http/handler"
 	chimw "github.com/sensetion/tgGitlabBot/internal/controller/http/middleware"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
 	"github.com/sensetion/tgGitlabBot/pkg/config"
+	"github.com/sensetion/tgGitlabBot/pkg/metrics"
 )
 
-func Init(cfg *config.Config) http.Handler {
+// Deps - зависимости HTTP-слоя
+type Deps struct {
+	Notifier        *usecase.Notifier
+	UnknownProjects *usecase.UnknownProjects
+	Repositories    *usecase.RepositoryService
+	// Idempotency - nil, если проверка повторов вебхуков отключена
+	Idempotency *usecase.Idempotency
+	// Outbox - nil, если события доставляются сразу, без сохранения в базе
+	Outbox *usecase.OutboxDispatcher
+	// DeadLetters - nil, если очередь недоставленных сообщений отключена
+	DeadLetters *usecase.DeadLetters
+	// Events - nil, если история событий не ведётся
+	Events *usecase.EventHistory
+	// Chats - nil, если настройки чатов не хранятся
+	Chats *usecase.ChatSettings
+	// Commands - nil, если обновления Telegram не принимаются вебхуком
+	Commands *usecase.CommandRouter
+	// Instances - инстансы GitLab, от которых принимаются вебхуки
+	Instances []chimw.GitLabInstance
+}
+
+func Init(cfg *config.Config, deps Deps) http.Handler {
 	r := chi.NewRouter()
 
 	setupRouter(r, cfg)
-	setupHandlers(r, cfg)
+	setupHandlers(r, cfg, deps)
 
 	return r
 }
 
-func setupHandlers(r *chi.Mux, cfg *config.Config) {
+func setupHandlers(r *chi.Mux, cfg *config.Config, deps Deps) {
 	healthHandler := handler.NewHealthHandler(nil)
-	webhookHandler := handler.NewWebhookHandler()
+	webhookHandler := handler.NewWebhookHandler(deps.Notifier, deps.Outbox, deps.Idempotency)
+	adminHandler := handler.NewAdminHandler(deps.UnknownProjects)
+	repositoriesHandler := handler.NewRepositoriesHandler(deps.Repositories)
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("GitLab Telegram Bot API"))
@@ -30,14 +56,87 @@ func setupHandlers(r *chi.Mux, cfg *config.Config) {
 
 	r.Get("/health", healthHandler.Health)
 	r.Get("/ready", healthHandler.Ready)
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
 
 	r.Route("/webhook", func(wr chi.Router) {
-		wr.Use(chimw.WebhookAuth(cfg.GitLab.WebhookSecret))
 		wr.Use(middleware.AllowContentType("application/json"))
 
-		wr.Post("/gitlab", webhookHandler.HandleGitLabPush)
+		// Аутентификация подключается к каждому маршруту через With: так ей доступен
+		// параметр {projectID}, который chi разбирает только при сопоставлении маршрута
+		auth := chimw.WebhookAuth(chimw.NewGitLabInstances(deps.Instances), deps.Notifier.WebhookSecrets)
+
+		wr.With(auth).Post("/gitlab", webhookHandler.HandleGitLabPush)
+		wr.With(auth).Post("/gitlab/dry-run", webhookHandler.DryRun)
+		wr.With(auth).Post("/gitlab/{projectID:[0-9]+}", webhookHandler.HandleGitLabPush)
+
+		// Отдельный путь для каждого инстанса GitLab, если заголовок X-Gitlab-Instance недоступен
+		wr.With(auth).Post("/gitlab/{instance:[a-z][a-z0-9_-]*}", webhookHandler.HandleGitLabPush)
+		wr.With(auth).Post("/gitlab/{instance:[a-z][a-z0-9_-]*}/dry-run", webhookHandler.DryRun)
+		wr.With(auth).Post("/gitlab/{instance:[a-z][a-z0-9_-]*}/{projectID:[0-9]+}", webhookHandler.HandleGitLabPush)
+
+		// Обновления Telegram в режиме вебхука (telegram.updates.mode: webhook)
+		if deps.Commands != nil {
+			telegramAuth := chimw.TelegramAuth(cfg.Telegram.Updates.Webhook.SecretToken.Value())
+			wr.With(telegramAuth).Post("/telegram", handler.NewTelegramHandler(deps.Commands).HandleUpdate)
+		}
 	})
 
+	// Административное API доступно только при заданном токене
+	if cfg.Admin.Token != "" {
+		r.Route("/admin", func(ar chi.Router) {
+			ar.Use(chimw.AdminAuth(cfg.Admin.Token.Value()))
+
+			ar.Get("/unknown-projects", adminHandler.UnknownProjects)
+			ar.Delete("/unknown-projects/{projectID}", adminHandler.ForgetUnknownProject)
+
+			ar.Route("/repositories", func(rr chi.Router) {
+				rr.Get("/", repositoriesHandler.List)
+				rr.Post("/", repositoriesHandler.Create)
+
+				rr.Get("/default-route", repositoriesHandler.DefaultRoute)
+				rr.Put("/default-route", repositoriesHandler.SetDefaultRoute)
+				rr.Delete("/default-route", repositoriesHandler.DeleteDefaultRoute)
+
+				rr.Get("/{projectID}/{chatID}", repositoriesHandler.Get)
+				rr.Put("/{projectID}/{chatID}", repositoriesHandler.Update)
+				rr.Delete("/{projectID}/{chatID}", repositoriesHandler.Delete)
+				rr.Post("/{projectID}/{chatID}/enable", repositoriesHandler.Enable)
+				rr.Post("/{projectID}/{chatID}/disable", repositoriesHandler.Disable)
+			})
+
+			if deps.Events != nil {
+				ar.Get("/events", handler.NewEventsHandler(deps.Events).List)
+			}
+
+			if deps.Chats != nil {
+				chatSettingsHandler := handler.NewChatSettingsHandler(deps.Chats)
+
+				ar.Route("/chat-settings", func(cr chi.Router) {
+					cr.Get("/", chatSettingsHandler.List)
+					cr.Get("/{chatID}", chatSettingsHandler.Get)
+					cr.Put("/{chatID}", chatSettingsHandler.Put)
+					cr.Delete("/{chatID}", chatSettingsHandler.Delete)
+					cr.Post("/{chatID}/mute", chatSettingsHandler.Mute)
+					cr.Post("/{chatID}/unmute", chatSettingsHandler.Unmute)
+				})
+			}
+
+			if deps.DeadLetters != nil {
+				deadLettersHandler := handler.NewDeadLettersHandler(deps.DeadLetters)
+
+				ar.Route("/dead-letters", func(dr chi.Router) {
+					dr.Get("/", deadLettersHandler.List)
+					dr.Delete("/", deadLettersHandler.Purge)
+					dr.Get("/{id:[0-9]+}", deadLettersHandler.Get)
+					dr.Delete("/{id:[0-9]+}", deadLettersHandler.Delete)
+					dr.Post("/{id:[0-9]+}/retry", deadLettersHandler.Retry)
+				})
+			}
+		})
+	} else {
+		log.Println("ℹ️ admin.token не задан, административное API отключено")
+	}
+
 	r.NotFound(func(w http.ResponseWriter, req *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte(`{"error":"route not found"}`))
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/sensetion/tgGitlabBot/pkg/config"
+	"github.com/sensetion/tgGitlabBot/pkg/tlscert"
+)
+
+// Server - HTTP-сервер, слушающий TCP-адрес или unix-сокет, с необязательным TLS
+type Server struct {
+	http     *http.Server
+	listener net.Listener
+	certs    *tlscert.Reloader
+	address  string
+}
+
+// NewServer открывает слушающий сокет сразу, чтобы ошибки (занятый порт, нет прав,
+// неверный сертификат) обнаруживались при старте, а не в фоновой горутине
+func NewServer(cfg config.ServerConfig, handler http.Handler) (*Server, error) {
+	s := &Server{
+		http: &http.Server{
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout, // Защита от Slowloris атак - тип DDoS-атаки, при которой
+			// атакующий умышленно медленно отправляет HTTP-заголовки, удерживая соединения открытыми и истощая
+			// пул доступных соединений на сервере. ReadHeaderTimeout принудительно закрывает соединение,
+			// если клиент не успел отправить все заголовки за отведенное время.
+		},
+	}
+
+	listener, err := listen(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.TLS.Enabled() {
+		s.certs, err = tlscert.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		s.http.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: s.certs.GetCertificate,
+		}
+		listener = tls.NewListener(listener, s.http.TLSConfig)
+	}
+
+	s.listener = listener
+	s.address = describeAddress(cfg)
+
+	return s, nil
+}
+
+// Serve обслуживает запросы до Shutdown; при TLS также следит за файлами сертификата до отмены ctx
+func (s *Server) Serve(ctx context.Context) error {
+	if s.certs != nil {
+		go func() {
+			if err := s.certs.Run(ctx); err != nil {
+				log.Printf("❌ Отслеживание TLS-сертификата остановлено: %v", err)
+			}
+		}()
+	}
+
+	log.Printf("🚀 HTTP-сервер запущен на %s\n", s.address)
+
+	err := s.http.Serve(s.listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// ReloadCertificate перечитывает TLS-сертификат (например, по SIGHUP); без TLS ничего не делает
+func (s *Server) ReloadCertificate() {
+	if s.certs != nil {
+		s.certs.Reload()
+	}
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+func (s *Server) Close() error {
+	return s.http.Close()
+}
+
+func listen(cfg config.ServerConfig) (net.Listener, error) {
+	if cfg.UnixSocket == "" {
+		address := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+		}
+		return listener, nil
+	}
+
+	mode, err := cfg.SocketMode()
+	if err != nil {
+		return nil, err
+	}
+
+	// Сокет, оставшийся после аварийного завершения, мешает повторному запуску
+	if info, err := os.Lstat(cfg.UnixSocket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(cfg.UnixSocket); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", cfg.UnixSocket, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", cfg.UnixSocket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix socket %s: %w", cfg.UnixSocket, err)
+	}
+
+	if err := os.Chmod(cfg.UnixSocket, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to chmod unix socket %s: %w", cfg.UnixSocket, err)
+	}
+
+	return listener, nil
+}
+
+func describeAddress(cfg config.ServerConfig) string {
+	scheme := "http"
+	if cfg.TLS.Enabled() {
+		scheme = "https"
+	}
+
+	if cfg.UnixSocket != "" {
+		return scheme + "+unix://" + cfg.UnixSocket
+	}
+
+	host := cfg.Host
+	if host == "" {
+		host = "0.0.0.0"
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+}
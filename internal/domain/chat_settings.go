@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrChatSettingsNotFound - для чата не сохранено настроек
+var ErrChatSettingsNotFound = errors.New("chat settings not found")
+
+// Языки уведомлений
+const (
+	LanguageRussian = "ru"
+	LanguageEnglish = "en"
+)
+
+// Verbosity - подробность уведомлений чата
+type Verbosity string
+
+const (
+	// VerbosityMinimal - одна строка: что произошло и ссылка
+	VerbosityMinimal Verbosity = "minimal"
+	// VerbosityNormal - обычное уведомление; список файлов - по show_files маршрута
+	VerbosityNormal Verbosity = "normal"
+	// VerbosityDetailed - со списком файлов и временем события в часовом поясе чата
+	VerbosityDetailed Verbosity = "detailed"
+)
+
+// ChatSettings - настройки чата, не зависящие от маршрутов repositories.json.
+// Пустые поля означают значение по умолчанию.
+type ChatSettings struct {
+	ChatID string `json:"chat_id"`
+	// Language - язык уведомлений: ru (по умолчанию) или en
+	Language string `json:"language,omitempty"`
+	// Timezone - часовой пояс IANA для времени в уведомлениях, например Europe/Moscow (по умолчанию UTC)
+	Timezone  string    `json:"timezone,omitempty"`
+	Verbosity Verbosity `json:"verbosity,omitempty"`
+	// MutedUntil - до этого момента уведомления в чат не отправляются
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+	// DefaultThread - ID темы форума (message_thread_id), в которую отправляются уведомления
+	DefaultThread int       `json:"default_thread,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Validate проверяет значения настроек
+func (s ChatSettings) Validate() error {
+	if s.ChatID == "" {
+		return errors.New("chat_id is required")
+	}
+
+	switch s.Language {
+	case "", LanguageRussian, LanguageEnglish:
+	default:
+		return fmt.Errorf("unknown language %q (expected %s or %s)", s.Language, LanguageRussian, LanguageEnglish)
+	}
+
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", s.Timezone)
+		}
+	}
+
+	switch s.Verbosity {
+	case "", VerbosityMinimal, VerbosityNormal, VerbosityDetailed:
+	default:
+		return fmt.Errorf("unknown verbosity %q (expected %s, %s or %s)",
+			s.Verbosity, VerbosityMinimal, VerbosityNormal, VerbosityDetailed)
+	}
+
+	if s.DefaultThread < 0 {
+		return errors.New("default_thread must not be negative")
+	}
+
+	return nil
+}
+
+// Muted проверяет, заглушён ли чат в момент t
+func (s ChatSettings) Muted(t time.Time) bool {
+	return s.MutedUntil != nil && t.Before(*s.MutedUntil)
+}
+
+// LanguageOrDefault возвращает язык уведомлений с учётом значения по умолчанию
+func (s ChatSettings) LanguageOrDefault() string {
+	if s.Language == "" {
+		return LanguageRussian
+	}
+	return s.Language
+}
+
+// VerbosityOrDefault возвращает подробность уведомлений с учётом значения по умолчанию
+func (s ChatSettings) VerbosityOrDefault() Verbosity {
+	if s.Verbosity == "" {
+		return VerbosityNormal
+	}
+	return s.Verbosity
+}
+
+// Location возвращает часовой пояс чата; UTC, если он не задан или неизвестен
+func (s ChatSettings) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -13,4 +13,7 @@ type CommitEvent struct {
 	Timestamp      time.Time
 	WebURL         string
 	CommitURL      string
+	CommitsCount   int
+	// ChangedFiles - уникальные пути файлов, добавленных, изменённых или удалённых во всех коммитах пуша
+	ChangedFiles []string
 }
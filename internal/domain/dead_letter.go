@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeadLetterNotFound - в очереди недоставленных нет сообщения с таким ID
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeliveryError - уведомление не доставлено после всех попыток отправки
+type DeliveryError struct {
+	ChatID   string
+	Attempts int
+	Err      error
+	// Permanent - повтор не поможет: бота удалили из чата, чат не найден, сообщение некорректно
+	Permanent bool
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("send message to chat %s: %v", e.ChatID, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error { return e.Err }
+
+// DeadLetter - уведомление, которое не удалось доставить (например, бота удалили из чата).
+// Хранится вместе с ошибкой и готовым текстом сообщения до ручного повтора или удаления.
+type DeadLetter struct {
+	ID           int64
+	Notification Notification
+	Error        string
+	Attempts     int
+	CreatedAt    time.Time
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/pkg/expr"
+)
+
+type EventKind string
+
+const (
+	EventKindPush         EventKind = "push"
+	EventKindMergeRequest EventKind = "merge_request"
+	EventKindPipeline     EventKind = "pipeline"
+	EventKindIssue        EventKind = "issue"
+	EventKindNote         EventKind = "note"
+)
+
+// Event - нормализованное событие GitLab, общее для всех типов хуков
+type Event struct {
+	Kind        EventKind
+	Instance    string // инстанс GitLab, приславший хук (определяется по запросу, а не по телу)
+	ProjectID   string
+	ProjectName string
+	ProjectURL  string
+	Ref         string // ветка (для MR - целевая ветка); у issue и комментариев вне MR пусто
+	Status      string // статус пайплайна или состояние MR/issue
+	Action      string // действие с MR или issue: open, update, merge, close...
+	Author      string
+	Title       string // заголовок MR/issue или сообщение последнего коммита
+	URL         string
+	ObjectID    int // ID пайплайна или комментария, IID merge request или issue
+	// MergeRequestIID - IID merge request, для которого запущен пайплайн или оставлен комментарий
+	// (0 - пайплайн ветки)
+	MergeRequestIID int
+	// IssueIID - IID issue, к которой оставлен комментарий
+	IssueIID int
+	// Note - текст комментария (только для EventKindNote)
+	Note      string
+	Timestamp time.Time
+	// Push - детали push-события (только для EventKindPush)
+	Push *CommitEvent
+}
+
+// Key возвращает ключ проекта события с учётом инстанса GitLab
+func (e *Event) Key() ProjectKey {
+	return ProjectKey{Instance: instanceOrDefault(e.Instance), ProjectID: e.ProjectID}
+}
+
+// Thread возвращает ключ объекта (merge request, пайплайн), к которому относится событие:
+// уведомления с одним ключом собираются в ветку ответов в чате. Пустая строка - событие без ветки.
+// Пайплайн merge request и комментарии к merge request или issue попадают в ветку самого объекта.
+func (e *Event) Thread() string {
+	switch {
+	case e.Kind == EventKindMergeRequest && e.ObjectID > 0:
+		return fmt.Sprintf("%s/merge_request/%d", e.Key(), e.ObjectID)
+	case e.Kind == EventKindIssue && e.ObjectID > 0:
+		return fmt.Sprintf("%s/issue/%d", e.Key(), e.ObjectID)
+	case (e.Kind == EventKindPipeline || e.Kind == EventKindNote) && e.MergeRequestIID > 0:
+		return fmt.Sprintf("%s/merge_request/%d", e.Key(), e.MergeRequestIID)
+	case e.Kind == EventKindNote && e.IssueIID > 0:
+		return fmt.Sprintf("%s/issue/%d", e.Key(), e.IssueIID)
+	case e.Kind == EventKindPipeline && e.ObjectID > 0:
+		return fmt.Sprintf("%s/pipeline/%d", e.Key(), e.ObjectID)
+	}
+	return ""
+}
+
+// Files возвращает изменённые файлы события (есть только у push-событий)
+func (e *Event) Files() []string {
+	if e.Push == nil {
+		return nil
+	}
+	return e.Push.ChangedFiles
+}
+
+// eventFields - поля события, доступные в условиях маршрутизации как event.<поле>
+var eventFields = map[string]struct{}{
+	"kind":       {},
+	"instance":   {},
+	"project_id": {},
+	"project":    {},
+	"ref":        {},
+	"status":     {},
+	"action":     {},
+	"author":     {},
+	"title":      {},
+	"files":      {},
+	"commits":    {},
+}
+
+// Env возвращает окружение для вычисления условий маршрутизации
+func (e *Event) Env() map[string]any {
+	commits := 0
+	if e.Push != nil {
+		commits = e.Push.CommitsCount
+	}
+
+	files := e.Files()
+	if files == nil {
+		files = []string{}
+	}
+
+	return map[string]any{
+		"event": map[string]any{
+			"kind":       string(e.Kind),
+			"instance":   instanceOrDefault(e.Instance),
+			"project_id": e.ProjectID,
+			"project":    e.ProjectName,
+			"ref":        e.Ref,
+			"status":     e.Status,
+			"action":     e.Action,
+			"author":     e.Author,
+			"title":      e.Title,
+			"files":      files,
+			"commits":    commits,
+		},
+	}
+}
+
+// CompileCondition компилирует условие маршрутизации и проверяет, что оно
+// ссылается только на известные поля события
+func CompileCondition(condition string) (*expr.Program, error) {
+	program, err := expr.Compile(condition)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ident := range program.Idents() {
+		field, ok := strings.CutPrefix(ident.Name, "event.")
+		if !ok {
+			return nil, &expr.Error{Pos: ident.Pos, Msg: fmt.Sprintf("unknown identifier %s, fields must be accessed as event.<field>", ident.Name)}
+		}
+		if _, known := eventFields[field]; !known {
+			return nil, &expr.Error{Pos: ident.Pos, Msg: "unknown field " + ident.Name}
+		}
+	}
+
+	return program, nil
+}
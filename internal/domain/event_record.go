@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrEventNotFound - в истории нет записи о событии
+var ErrEventNotFound = errors.New("event not found")
+
+// EventOutcome - итог доставки уведомлений о событии
+type EventOutcome string
+
+const (
+	// OutcomePending - уведомления ещё в очереди доставки
+	OutcomePending EventOutcome = "pending"
+	// OutcomeDelivered - доставлены во все подходящие чаты
+	OutcomeDelivered EventOutcome = "delivered"
+	// OutcomePartial - доставлены не во все чаты
+	OutcomePartial EventOutcome = "partial"
+	// OutcomeFailed - не доставлено ни одного уведомления
+	OutcomeFailed EventOutcome = "failed"
+	// OutcomeSkipped - ни один маршрут не подошёл
+	OutcomeSkipped EventOutcome = "skipped"
+	// OutcomeHeld - часть уведомлений отложена до окончания тихих часов, остальные доставлены
+	OutcomeHeld EventOutcome = "held"
+)
+
+// EventRecord - запись истории событий: нормализованное событие и итог его доставки
+type EventRecord struct {
+	ID          int64     `json:"id"`
+	Instance    string    `json:"instance"`
+	ProjectID   string    `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	Kind        EventKind `json:"kind"`
+	Ref         string    `json:"ref,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Action      string    `json:"action,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	// OccurredAt - время события в GitLab; если GitLab его не передал - время получения
+	OccurredAt time.Time `json:"occurred_at"`
+	ReceivedAt time.Time `json:"received_at"`
+	// OutboxID - запись outbox, из которой доставляется событие (0 - без outbox)
+	OutboxID   int64           `json:"outbox_id,omitempty"`
+	Outcome    EventOutcome    `json:"outcome"`
+	Deliveries []EventDelivery `json:"deliveries"`
+}
+
+// EventDelivery - решение по одному маршруту и результат отправки в его чат
+type EventDelivery struct {
+	ChatID  string `json:"chat_id"`
+	Matched bool   `json:"matched"`
+	// Reason - почему маршрут не подошёл
+	Reason string `json:"reason,omitempty"`
+	// Error - ошибка отправки; пусто при успешной доставке
+	Error string `json:"error,omitempty"`
+	// Done - попытка отправки завершена
+	Done bool `json:"done,omitempty"`
+	// Held - уведомление отложено до окончания тихих часов чата (отправка позже в историю не попадает)
+	Held bool `json:"held,omitempty"`
+}
+
+// EventFilter - условия выборки из истории событий. Пустые поля не ограничивают выборку.
+type EventFilter struct {
+	Instance string
+	// Project - ID проекта или его имя
+	Project string
+	Ref     string
+	Author  string
+	Kind    EventKind
+	Outcome EventOutcome
+	// Since и Until ограничивают время события в GitLab (OccurredAt)
+	Since time.Time
+	Until time.Time
+	// Before - курсор: только записи с ID меньше заданного (0 - с самых новых)
+	Before int64
+	Limit  int
+}
+
+// NewEventRecord заполняет запись истории по событию
+func NewEventRecord(event *Event, receivedAt time.Time) EventRecord {
+	occurredAt := event.Timestamp
+	if occurredAt.IsZero() {
+		occurredAt = receivedAt
+	}
+
+	return EventRecord{
+		Instance:    instanceOrDefault(event.Instance),
+		ProjectID:   event.ProjectID,
+		ProjectName: event.ProjectName,
+		Kind:        event.Kind,
+		Ref:         event.Ref,
+		Author:      event.Author,
+		Title:       event.Title,
+		Status:      event.Status,
+		Action:      event.Action,
+		URL:         event.URL,
+		OccurredAt:  occurredAt,
+		ReceivedAt:  receivedAt,
+		Outcome:     OutcomePending,
+	}
+}
@@ -0,0 +1,23 @@
+package domain
+
+// DefaultInstance - имя инстанса GitLab из секции gitlab конфигурации (webhook_secret, base_url).
+// Используется для репозиториев и событий, у которых инстанс не указан.
+const DefaultInstance = "default"
+
+// ProjectKey однозначно определяет проект: ID проектов разных инстансов GitLab могут совпадать
+type ProjectKey struct {
+	Instance  string
+	ProjectID string
+}
+
+func (k ProjectKey) String() string {
+	return k.Instance + "/" + k.ProjectID
+}
+
+// instanceOrDefault возвращает имя инстанса или DefaultInstance, если оно не задано
+func instanceOrDefault(instance string) string {
+	if instance == "" {
+		return DefaultInstance
+	}
+	return instance
+}
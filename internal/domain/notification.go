@@ -1,9 +1,50 @@
 package domain
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrHeld - уведомление не отправлено, а отложено до окончания тихих часов чата.
+// Это не ошибка доставки: сообщение сохранено и будет отправлено позже.
+var ErrHeld = errors.New("notification held until the end of quiet hours")
+
 // Notification представляет уведомление для отправки в Telegram
 type Notification struct {
 	ChatID     string
 	Message    string
 	ParseMode  string // "Markdown" или "HTML"
 	RetryCount int
+	Priority   Priority
+	// DisableNotification - доставить сообщение без звука
+	DisableNotification bool
+	// Pin - закрепить сообщение в чате после отправки
+	Pin bool
+	// MessageThreadID - тема форума, в которую отправляется сообщение (0 - основной чат)
+	MessageThreadID int
+	// Thread - ключ объекта GitLab (см. Event.Thread); сообщения с одним ключом отправляются ответами
+	// на первое сообщение о нём в чате
+	Thread string
+	// ReplyToMessageID - ID сообщения в чате, ответом на которое отправляется уведомление
+	ReplyToMessageID int
+	// Buttons - inline-кнопки под сообщением (одним рядом)
+	Buttons []Button
+	// Done вызывается очередью доставки после попытки отправки (nil - не нужно)
+	Done func(err error) `json:"-"`
+	// Sent вызывается отправителем с ID отправленного в Telegram сообщения (nil - не нужно)
+	Sent func(messageID int) `json:"-"`
+}
+
+// HeldMessage - уведомление, отложенное до окончания тихих часов
+type HeldMessage struct {
+	// ID присваивает хранилище; по нему доставленное сообщение удаляется из отложенных
+	ID           int64
+	Notification Notification
+	HeldAt       time.Time
+}
+
+// QueuedNotification - уведомление в очереди доставки с номером, присвоенным хранилищем очереди
+type QueuedNotification struct {
+	ID           uint64
+	Notification Notification
 }
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// OutboxEntry - принятое событие GitLab, сохранённое до доставки уведомлений.
+// Запись остаётся в outbox, пока все уведомления по событию не будут обработаны очередью доставки,
+// поэтому события, принятые до аварийного завершения, доставляются после перезапуска.
+type OutboxEntry struct {
+	ID        int64
+	Event     *Event
+	Attempts  int
+	LastError string
+	// DeliveredChats - чаты, уведомления в которые приняты в прошлых попытках; повтор их пропускает
+	DeliveredChats []string
+	CreatedAt      time.Time
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietMode определяет, что делать с сообщением в тихие часы
+type QuietMode string
+
+const (
+	// QuietModeSilent - отправлять без звука (disable_notification)
+	QuietModeSilent QuietMode = "silent"
+	// QuietModeHold - копить и отправить одной пачкой после окончания тихих часов
+	QuietModeHold QuietMode = "hold"
+)
+
+// QuietWindow - ежедневный интервал тишины в минутах от полуночи.
+// Если End меньше Start, интервал переходит через полночь (22:00-08:00).
+type QuietWindow struct {
+	Start int
+	End   int
+}
+
+// ParseQuietWindow разбирает интервал вида "22:00" - "08:00"
+func ParseQuietWindow(start, end string) (QuietWindow, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return QuietWindow{}, fmt.Errorf("invalid start %q: %w", start, err)
+	}
+
+	e, err := parseClock(end)
+	if err != nil {
+		return QuietWindow{}, fmt.Errorf("invalid end %q: %w", end, err)
+	}
+
+	if s == e {
+		return QuietWindow{}, fmt.Errorf("start and end must differ")
+	}
+
+	return QuietWindow{Start: s, End: e}, nil
+}
+
+// Contains проверяет, попадает ли время суток (в минутах) в интервал
+func (w QuietWindow) Contains(minute int) bool {
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// QuietSchedule - расписание тихих часов чата
+type QuietSchedule struct {
+	Location *time.Location
+	Mode     QuietMode
+	Windows  []QuietWindow
+	// WeekendDays - дни, которые целиком считаются тихими
+	WeekendDays []time.Weekday
+}
+
+// IsQuiet проверяет, действуют ли тихие часы в момент t
+func (s *QuietSchedule) IsQuiet(t time.Time) bool {
+	local := t.In(s.Location)
+
+	for _, day := range s.WeekendDays {
+		if local.Weekday() == day {
+			return true
+		}
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	for _, w := range s.Windows {
+		if w.Contains(minute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWeekday разбирает сокращённое название дня недели: mon, tue, ... sun
+func ParseWeekday(name string) (time.Weekday, error) {
+	day, ok := weekdays[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown weekday %q (expected mon, tue, wed, thu, fri, sat or sun)", name)
+	}
+	return day, nil
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -1,10 +1,93 @@
 package domain
 
+import (
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/pkg/glob"
+)
+
 type Repository struct {
+	// Instance - имя инстанса GitLab, которому принадлежит проект; пусто - инстанс по умолчанию
+	Instance       string   `json:"instance,omitempty" mapstructure:"instance"`
 	ID             string   `json:"id" mapstructure:"id"`
 	TelegramChatID string   `json:"telegram_channel_id" mapstructure:"telegram_channel_id"`
 	Branches       []string `json:"branches" mapstructure:"branches"`
-	Enabled        bool     `json:"enabled" mapstructure:"enabled"`
+	Paths          []string `json:"paths,omitempty" mapstructure:"paths"`
+	ShowFiles      bool     `json:"show_files,omitempty" mapstructure:"show_files"`
+	// Condition - выражение, которому должно удовлетворять событие,
+	// например: event.kind == "pipeline" && event.status == "failed"
+	Condition string `json:"condition,omitempty" mapstructure:"condition"`
+	// WebhookSecret - собственный секрет вебхука проекта вместо общего gitlab.webhook_secret
+	WebhookSecret Secret `json:"webhook_secret,omitempty" mapstructure:"webhook_secret"`
+	// WebhookSecrets - дополнительные секреты со сроком действия для ротации без простоя
+	WebhookSecrets []WebhookSecret `json:"webhook_secrets,omitempty" mapstructure:"webhook_secrets"`
+	Enabled        bool            `json:"enabled" mapstructure:"enabled"`
+}
+
+// WebhookSecret - секрет вебхука, который принимается до ExpiresAt (если задан)
+type WebhookSecret struct {
+	Secret    Secret     `json:"secret" mapstructure:"secret"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" mapstructure:"expires_at"`
+}
+
+// Key возвращает ключ проекта с учётом инстанса GitLab
+func (r *Repository) Key() ProjectKey {
+	return ProjectKey{Instance: instanceOrDefault(r.Instance), ProjectID: r.ID}
+}
+
+// HasWebhookSecrets проверяет, заданы ли у репозитория собственные секреты вебхука
+func (r *Repository) HasWebhookSecrets() bool {
+	return r.WebhookSecret != "" || len(r.WebhookSecrets) > 0
+}
+
+// ActiveWebhookSecrets возвращает секреты, действующие в момент now
+func (r *Repository) ActiveWebhookSecrets(now time.Time) []string {
+	secrets := make([]string, 0, len(r.WebhookSecrets)+1)
+
+	if r.WebhookSecret != "" {
+		secrets = append(secrets, r.WebhookSecret.Value())
+	}
+
+	for _, s := range r.WebhookSecrets {
+		if s.Secret == "" || (s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)) {
+			continue
+		}
+		secrets = append(secrets, s.Secret.Value())
+	}
+
+	return secrets
+}
+
+// WithoutSecrets возвращает копию репозитория без секретов (для вывода в API и логи)
+func (r Repository) WithoutSecrets() Repository {
+	r.WebhookSecret = ""
+	r.WebhookSecrets = nil
+	return r
+}
+
+// KeepSecrets подставляет секреты stored вместо скрытых (***): у секрета webhook_secret -
+// текущее значение, у элемента webhook_secrets - значение элемента stored с тем же индексом.
+// Возвращает false, если для скрытого элемента webhook_secrets нет сохранённого значения.
+func (r *Repository) KeepSecrets(stored Repository) bool {
+	if r.WebhookSecret.Redacted() {
+		r.WebhookSecret = stored.WebhookSecret
+	}
+
+	secrets := make([]WebhookSecret, len(r.WebhookSecrets))
+	for i, s := range r.WebhookSecrets {
+		if s.Secret.Redacted() {
+			if i >= len(stored.WebhookSecrets) {
+				return false
+			}
+			s.Secret = stored.WebhookSecrets[i].Secret
+		}
+		secrets[i] = s
+	}
+	if r.WebhookSecrets != nil {
+		r.WebhookSecrets = secrets
+	}
+
+	return true
 }
 
 // HasBranch проверяет, нужно ли мониторить данную ветку
@@ -24,6 +107,31 @@ func (r *Repository) HasBranch(branch string) bool {
 	return false
 }
 
+// HasPathFilter проверяет, заданы ли фильтры по путям файлов
+func (r *Repository) HasPathFilter() bool {
+	return len(r.Paths) > 0
+}
+
+// MatchFiles возвращает файлы, попадающие хотя бы под один шаблон из Paths.
+// Если фильтры не заданы, возвращаются все файлы.
+func (r *Repository) MatchFiles(files []string) []string {
+	if !r.HasPathFilter() {
+		return files
+	}
+
+	matched := make([]string, 0, len(files))
+	for _, file := range files {
+		for _, pattern := range r.Paths {
+			if glob.Match(pattern, file) {
+				matched = append(matched, file)
+				break
+			}
+		}
+	}
+
+	return matched
+}
+
 // IsEnabled проверяет, активен ли репозиторий
 func (r *Repository) IsEnabled() bool {
 	return r.Enabled
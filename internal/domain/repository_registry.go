@@ -0,0 +1,44 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrRepositoryNotFound - записи репозитория с таким ключом нет
+	ErrRepositoryNotFound = errors.New("repository not found")
+	// ErrRepositoryExists - запись с таким ключом уже есть
+	ErrRepositoryExists = errors.New("repository already exists")
+	// ErrRegistryReadOnly - реестр репозиториев не поддерживает изменения (например, repositories.json)
+	ErrRegistryReadOnly = errors.New("repository registry is read-only")
+)
+
+// RouteKey однозначно определяет запись репозитория: один проект может доставлять события в несколько чатов
+type RouteKey struct {
+	Instance  string `json:"instance"`
+	ProjectID string `json:"id"`
+	ChatID    string `json:"telegram_channel_id"`
+}
+
+func (k RouteKey) String() string {
+	return k.Instance + "/" + k.ProjectID + "/" + k.ChatID
+}
+
+// RouteKey возвращает ключ записи репозитория
+func (r *Repository) RouteKey() RouteKey {
+	return RouteKey{Instance: instanceOrDefault(r.Instance), ProjectID: r.ID, ChatID: r.TelegramChatID}
+}
+
+// RepositorySet - полная конфигурация маршрутизации: репозитории и маршрут по умолчанию
+type RepositorySet struct {
+	Repositories []Repository `json:"repositories"`
+	DefaultRoute *Repository  `json:"default_route,omitempty"`
+}
+
+// Index возвращает позицию записи с ключом key или -1
+func (s *RepositorySet) Index(key RouteKey) int {
+	for i := range s.Repositories {
+		if s.Repositories[i].RouteKey() == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "log/slog"
+
+// redacted - представление непустого секрета в логах, JSON, YAML и выводе fmt
+const redacted = "***"
+
+// Secret - строка с секретом (токен, пароль). При выводе через fmt, encoding/json, YAML и slog
+// вместо значения печатается ***, поэтому секрет не попадает в логи при выводе структур целиком.
+// Настоящее значение доступно только через Value.
+type Secret string
+
+// Value возвращает значение секрета
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String скрывает значение; пустой секрет остаётся пустым, чтобы было видно, что он не задан
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString скрывает значение при выводе через %#v
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+// Redacted проверяет, что вместо значения передано его скрытое представление ***
+// (например, запись, полученная из API, отправлена обратно без изменений)
+func (s Secret) Redacted() bool {
+	return s == redacted
+}
+
+// MarshalText используется encoding/json и YAML
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Secret) UnmarshalText(text []byte) error {
+	*s = Secret(text)
+	return nil
+}
+
+// LogValue скрывает значение в slog
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
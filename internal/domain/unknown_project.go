@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// UnknownProject - проект, от которого приходят хуки, но которого нет в repositories.json
+type UnknownProject struct {
+	Instance    string    `json:"instance"`
+	ProjectID   string    `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	Count       int64     `json:"count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	LastKind    EventKind `json:"last_kind"`
+}
@@ -0,0 +1,38 @@
+package domain
+
+// Update - входящее обновление Telegram: сообщение в чате или нажатие inline-кнопки.
+// Заполнено одно из полей Message и Callback; остальные типы обновлений не разбираются.
+type Update struct {
+	ID       int
+	Message  *IncomingMessage
+	Callback *CallbackQuery
+}
+
+// IncomingMessage - текстовое сообщение, полученное ботом
+type IncomingMessage struct {
+	ID     int
+	ChatID string
+	// ThreadID - тема форума, в которой написано сообщение (0 - основной чат)
+	ThreadID int
+	UserID   int64
+	Username string
+	Text     string
+}
+
+// CallbackQuery - нажатие inline-кнопки под сообщением бота
+type CallbackQuery struct {
+	ID        string
+	ChatID    string
+	MessageID int
+	ThreadID  int
+	UserID    int64
+	Username  string
+	// Data - данные кнопки (Button.Data)
+	Data string
+}
+
+// Button - inline-кнопка под сообщением; при нажатии бот получает CallbackQuery с Data
+type Button struct {
+	Text string
+	Data string
+}
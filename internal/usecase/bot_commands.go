@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// defaultMuteDuration - срок заглушения командой /mute без аргумента
+const defaultMuteDuration = time.Hour
+
+// muteButtons - варианты срока под ответом на /mute
+var muteButtons = []domain.Button{
+	{Text: "1 ч", Data: "mute 1h"},
+	{Text: "4 ч", Data: "mute 4h"},
+	{Text: "1 день", Data: "mute 24h"},
+	{Text: "Включить", Data: "unmute"},
+}
+
+// RegisterChatCommands регистрирует команды настройки чата, в котором они отправлены.
+// Просмотр настроек доступен всем, изменение в группах - только администраторам чата.
+func RegisterChatCommands(router *CommandRouter, chats *ChatSettings) {
+	c := chatCommands{chats: chats}
+
+	router.Handle("settings", "настройки уведомлений этого чата", c.settings)
+	router.HandleAdmin("mute", "заглушить уведомления: /mute 2h (по умолчанию 1h), пропущенные не придут позже", c.mute)
+	router.HandleAdmin("unmute", "снова включить уведомления", c.unmute)
+	router.HandleAdmin("language", "язык уведомлений: /language ru|en", c.language)
+	router.HandleAdmin("verbosity", "подробность: /verbosity minimal|normal|detailed", c.verbosity)
+	router.HandleAdmin("timezone", "часовой пояс: /timezone Europe/Moscow", c.timezone)
+}
+
+type chatCommands struct {
+	chats *ChatSettings
+}
+
+func (c chatCommands) settings(_ context.Context, cmd Command) (CommandReply, error) {
+	settings := c.chats.Get(cmd.ChatID)
+
+	muted := "нет"
+	if settings.Muted(time.Now()) {
+		muted = "до " + settings.MutedUntil.In(settings.Location()).Format("02.01.2006 15:04 MST")
+	}
+	timezone := settings.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	text := fmt.Sprintf("⚙️ <b>Настройки чата</b>\nЯзык: %s\nПодробность: %s\nЧасовой пояс: %s\nЗаглушён: %s",
+		settings.LanguageOrDefault(),
+		settings.VerbosityOrDefault(),
+		html.EscapeString(timezone),
+		muted,
+	)
+	return CommandReply{Text: text}, nil
+}
+
+func (c chatCommands) mute(ctx context.Context, cmd Command) (CommandReply, error) {
+	d := defaultMuteDuration
+	if len(cmd.Args) > 0 {
+		parsed, err := time.ParseDuration(cmd.Args[0])
+		if err != nil || parsed <= 0 {
+			return CommandReply{Text: "Укажите срок, например: /mute 30m или /mute 2h"}, nil
+		}
+		d = parsed
+	}
+
+	settings, err := c.chats.Mute(ctx, cmd.ChatID, time.Now().Add(d))
+	if err != nil {
+		return commandError(err)
+	}
+
+	reply := CommandReply{
+		Text: "🔕 Уведомления заглушены до " +
+			settings.MutedUntil.In(settings.Location()).Format("02.01.2006 15:04 MST") +
+			". Уведомления за это время не будут доставлены и позже (их можно найти в истории событий)",
+	}
+	// Кнопки - только под ответом на команду: нажатие кнопки отвечает всплывающим уведомлением
+	if cmd.CallbackID == "" {
+		reply.Buttons = muteButtons
+	}
+	return reply, nil
+}
+
+func (c chatCommands) unmute(ctx context.Context, cmd Command) (CommandReply, error) {
+	if _, err := c.chats.Mute(ctx, cmd.ChatID, time.Time{}); err != nil {
+		return commandError(err)
+	}
+	return CommandReply{Text: "🔔 Уведомления включены"}, nil
+}
+
+func (c chatCommands) language(ctx context.Context, cmd Command) (CommandReply, error) {
+	return c.update(ctx, cmd, "Укажите язык: /language ru или /language en", func(s *domain.ChatSettings, v string) {
+		s.Language = strings.ToLower(v)
+	})
+}
+
+func (c chatCommands) verbosity(ctx context.Context, cmd Command) (CommandReply, error) {
+	return c.update(ctx, cmd, "Укажите подробность: /verbosity minimal, normal или detailed", func(s *domain.ChatSettings, v string) {
+		s.Verbosity = domain.Verbosity(strings.ToLower(v))
+	})
+}
+
+func (c chatCommands) timezone(ctx context.Context, cmd Command) (CommandReply, error) {
+	return c.update(ctx, cmd, "Укажите часовой пояс IANA, например: /timezone Europe/Moscow", func(s *domain.ChatSettings, v string) {
+		s.Timezone = v
+	})
+}
+
+// update меняет одно поле настроек чата значением из первого аргумента команды
+func (c chatCommands) update(ctx context.Context, cmd Command, usage string, apply func(*domain.ChatSettings, string)) (CommandReply, error) {
+	if len(cmd.Args) != 1 {
+		return CommandReply{Text: usage}, nil
+	}
+
+	settings := c.chats.Get(cmd.ChatID)
+	apply(&settings, cmd.Args[0])
+
+	if _, err := c.chats.Put(ctx, settings); err != nil {
+		return commandError(err)
+	}
+	return CommandReply{Text: "✅ Настройки сохранены"}, nil
+}
+
+// commandError превращает ошибку проверки настроек в ответ пользователю; остальные ошибки - внутренние
+func commandError(err error) (CommandReply, error) {
+	var validation *ValidationError
+	if errors.As(err, &validation) {
+		return CommandReply{Text: "⚠️ " + html.EscapeString(validation.Err.Error())}, nil
+	}
+	return CommandReply{}, err
+}
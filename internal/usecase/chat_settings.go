@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// ChatSettingsStore - постоянное хранилище настроек чатов
+type ChatSettingsStore interface {
+	List(ctx context.Context) ([]domain.ChatSettings, error)
+	// Put сохраняет настройки чата, заменяя существующие
+	Put(ctx context.Context, settings domain.ChatSettings) error
+	Delete(ctx context.Context, chatID string) error
+}
+
+// ChatSettings - настройки чатов (язык, часовой пояс, подробность, заглушение, тема форума).
+// Настройки читаются при каждом уведомлении, поэтому хранятся в памяти целиком
+// и записываются в хранилище при изменении. Nil-значение отдаёт настройки по умолчанию.
+type ChatSettings struct {
+	// mu упорядочивает изменения: запись в хранилище и кэш выполняется вместе
+	mu       sync.Mutex
+	store    ChatSettingsStore
+	cacheMu  sync.RWMutex
+	settings map[string]domain.ChatSettings
+	now      func() time.Time
+}
+
+// NewChatSettings загружает настройки всех чатов из хранилища
+func NewChatSettings(ctx context.Context, store ChatSettingsStore) (*ChatSettings, error) {
+	list, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chat settings: %w", err)
+	}
+
+	settings := make(map[string]domain.ChatSettings, len(list))
+	for _, s := range list {
+		settings[s.ChatID] = s
+	}
+
+	return &ChatSettings{
+		store:    store,
+		settings: settings,
+		now:      time.Now,
+	}, nil
+}
+
+// Get возвращает настройки чата; для чата без сохранённых настроек - значения по умолчанию
+func (c *ChatSettings) Get(chatID string) domain.ChatSettings {
+	settings, _ := c.Lookup(chatID)
+	return settings
+}
+
+// Lookup возвращает настройки чата и признак того, что они сохранены
+func (c *ChatSettings) Lookup(chatID string) (domain.ChatSettings, bool) {
+	if c == nil {
+		return domain.ChatSettings{ChatID: chatID}, false
+	}
+
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+
+	settings, ok := c.settings[chatID]
+	if !ok {
+		return domain.ChatSettings{ChatID: chatID}, false
+	}
+	return settings, true
+}
+
+// List возвращает сохранённые настройки всех чатов, упорядоченные по chat_id
+func (c *ChatSettings) List() []domain.ChatSettings {
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+
+	list := make([]domain.ChatSettings, 0, len(c.settings))
+	for _, s := range c.settings {
+		list = append(list, s)
+	}
+	slices.SortFunc(list, func(a, b domain.ChatSettings) int { return strings.Compare(a.ChatID, b.ChatID) })
+	return list
+}
+
+// Put проверяет и сохраняет настройки чата целиком
+func (c *ChatSettings) Put(ctx context.Context, settings domain.ChatSettings) (domain.ChatSettings, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.put(ctx, settings)
+}
+
+// Mute заглушает чат до until; нулевое until снимает заглушение
+func (c *ChatSettings) Mute(ctx context.Context, chatID string, until time.Time) (domain.ChatSettings, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	settings, _ := c.Lookup(chatID)
+	settings.MutedUntil = nil
+	if !until.IsZero() {
+		settings.MutedUntil = &until
+	}
+	return c.put(ctx, settings)
+}
+
+// Delete удаляет настройки чата: дальше действуют значения по умолчанию
+func (c *ChatSettings) Delete(ctx context.Context, chatID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.store.Delete(ctx, chatID); err != nil {
+		return err
+	}
+
+	c.cacheMu.Lock()
+	delete(c.settings, chatID)
+	c.cacheMu.Unlock()
+
+	return nil
+}
+
+func (c *ChatSettings) put(ctx context.Context, settings domain.ChatSettings) (domain.ChatSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return settings, &ValidationError{Err: err}
+	}
+	if settings.MutedUntil != nil {
+		until := settings.MutedUntil.UTC()
+		settings.MutedUntil = &until
+	}
+	settings.UpdatedAt = c.now().UTC()
+
+	if err := c.store.Put(ctx, settings); err != nil {
+		return settings, fmt.Errorf("failed to store chat settings: %w", err)
+	}
+
+	c.cacheMu.Lock()
+	c.settings[settings.ChatID] = settings
+	c.cacheMu.Unlock()
+
+	return settings, nil
+}
@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/pkg/metrics"
+)
+
+var botCommands = metrics.NewCounterVec(
+	"tgbot_bot_commands_total",
+	"Bot commands received from Telegram, by command (unknown - not registered)",
+	"command",
+)
+
+// CommandReplier - методы Telegram для ответа на команды
+type CommandReplier interface {
+	SendMessage(ctx context.Context, n domain.Notification) error
+	AnswerCallbackQuery(ctx context.Context, id, text string) error
+	// ChatMemberStatus возвращает статус пользователя в чате (creator, administrator, member...)
+	ChatMemberStatus(ctx context.Context, chatID string, userID int64) (string, error)
+}
+
+// Command - команда бота из сообщения ("/mute 2h") или из данных нажатой кнопки ("mute 2h")
+type Command struct {
+	Name     string
+	Args     []string
+	ChatID   string
+	ThreadID int
+	// MessageID - сообщение с командой или сообщение с нажатой кнопкой
+	MessageID int
+	UserID    int64
+	Username  string
+	// CallbackID - непустой, если команда пришла нажатием кнопки
+	CallbackID string
+}
+
+// CommandReply - ответ на команду. На сообщение бот отвечает сообщением с кнопками,
+// на нажатие кнопки - всплывающим уведомлением с Text.
+type CommandReply struct {
+	// Text - HTML-текст ответа; пустой - не отвечать
+	Text    string
+	Buttons []domain.Button
+}
+
+// CommandHandler выполняет команду. Ошибка означает внутренний сбой: пользователь получит
+// общее сообщение об ошибке, а ошибки ввода описываются текстом ответа.
+type CommandHandler func(ctx context.Context, cmd Command) (CommandReply, error)
+
+type registeredCommand struct {
+	description string
+	handler     CommandHandler
+	// admin - в группах команда доступна только администраторам чата
+	admin bool
+}
+
+// CommandRouter передаёт команды из обновлений Telegram зарегистрированным обработчикам.
+// Обработчики регистрируются при запуске, до начала получения обновлений.
+type CommandRouter struct {
+	replier  CommandReplier
+	username string
+	commands map[string]registeredCommand
+	// order - порядок команд в /help
+	order []string
+}
+
+// NewCommandRouter создаёт маршрутизатор с командой /help. username - имя бота без @:
+// команды вида /cmd@other_bot в группах игнорируются; пустое значение принимает все.
+func NewCommandRouter(replier CommandReplier, username string) *CommandRouter {
+	r := &CommandRouter{
+		replier:  replier,
+		username: username,
+		commands: make(map[string]registeredCommand),
+	}
+	r.Handle("help", "список команд", r.help)
+
+	return r
+}
+
+// Handle регистрирует обработчик команды name (без /)
+func (r *CommandRouter) Handle(name, description string, handler CommandHandler) {
+	r.register(name, registeredCommand{description: description, handler: handler})
+}
+
+// HandleAdmin регистрирует команду, которую в группах могут выполнять только администраторы чата
+// (права проверяются через getChatMember при каждом вызове); в личном чате она доступна собеседнику
+func (r *CommandRouter) HandleAdmin(name, description string, handler CommandHandler) {
+	r.register(name, registeredCommand{description: description, handler: handler, admin: true})
+}
+
+func (r *CommandRouter) register(name string, command registeredCommand) {
+	if _, ok := r.commands[name]; !ok {
+		r.order = append(r.order, name)
+	}
+	r.commands[name] = command
+}
+
+// Dispatch разбирает обновление и выполняет команду; сообщения без команды игнорируются
+func (r *CommandRouter) Dispatch(ctx context.Context, update domain.Update) {
+	switch {
+	case update.Message != nil:
+		cmd, ok := r.parseMessage(update.Message)
+		if !ok {
+			return
+		}
+		r.run(ctx, cmd)
+	case update.Callback != nil:
+		q := update.Callback
+		fields := strings.Fields(q.Data)
+		if len(fields) == 0 {
+			r.answer(ctx, q.ID, "")
+			return
+		}
+		r.run(ctx, Command{
+			Name:       strings.ToLower(fields[0]),
+			Args:       fields[1:],
+			ChatID:     q.ChatID,
+			ThreadID:   q.ThreadID,
+			MessageID:  q.MessageID,
+			UserID:     q.UserID,
+			Username:   q.Username,
+			CallbackID: q.ID,
+		})
+	}
+}
+
+// parseMessage разбирает "/name@bot arg1 arg2"
+func (r *CommandRouter) parseMessage(m *domain.IncomingMessage) (Command, bool) {
+	fields := strings.Fields(m.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return Command{}, false
+	}
+
+	name, target, addressed := strings.Cut(fields[0][1:], "@")
+	if addressed && r.username != "" && !strings.EqualFold(target, r.username) {
+		// Команда другому боту в группе
+		return Command{}, false
+	}
+
+	return Command{
+		Name:      strings.ToLower(name),
+		Args:      fields[1:],
+		ChatID:    m.ChatID,
+		ThreadID:  m.ThreadID,
+		MessageID: m.ID,
+		UserID:    m.UserID,
+		Username:  m.Username,
+	}, true
+}
+
+func (r *CommandRouter) run(ctx context.Context, cmd Command) {
+	registered, ok := r.commands[cmd.Name]
+	if !ok {
+		botCommands.Inc("unknown")
+		// В группах неизвестные команды могут быть адресованы другим ботам - отвечаем только в личке
+		// и на нажатия кнопок, которые иначе остались бы с часами загрузки
+		if cmd.CallbackID != "" || !isGroupChat(cmd.ChatID) {
+			r.reply(ctx, cmd, CommandReply{Text: "Неизвестная команда. Список команд: /help"})
+		}
+		return
+	}
+	botCommands.Inc(cmd.Name)
+
+	log.Printf("🤖 Команда /%s в чате %s от %s", cmd.Name, cmd.ChatID, commandAuthor(cmd))
+
+	if registered.admin && isGroupChat(cmd.ChatID) {
+		status, err := r.replier.ChatMemberStatus(ctx, cmd.ChatID, cmd.UserID)
+		if err != nil {
+			log.Printf("❌ Не удалось проверить права %s в чате %s: %v", commandAuthor(cmd), cmd.ChatID, err)
+			r.reply(ctx, cmd, CommandReply{Text: "⚠️ Не удалось проверить права, попробуйте позже"})
+			return
+		}
+		if status != "creator" && status != "administrator" {
+			log.Printf("⛔ Команда /%s в чате %s отклонена: %s не администратор", cmd.Name, cmd.ChatID, commandAuthor(cmd))
+			r.reply(ctx, cmd, CommandReply{Text: "⛔ Команда доступна только администраторам чата"})
+			return
+		}
+	}
+
+	reply, err := registered.handler(ctx, cmd)
+	if err != nil {
+		log.Printf("❌ Ошибка команды /%s в чате %s: %v", cmd.Name, cmd.ChatID, err)
+		reply = CommandReply{Text: "⚠️ Не удалось выполнить команду, попробуйте позже"}
+	}
+
+	r.reply(ctx, cmd, reply)
+}
+
+func (r *CommandRouter) reply(ctx context.Context, cmd Command, reply CommandReply) {
+	if cmd.CallbackID != "" {
+		r.answer(ctx, cmd.CallbackID, reply.Text)
+		return
+	}
+	if reply.Text == "" {
+		return
+	}
+
+	err := r.replier.SendMessage(ctx, domain.Notification{
+		ChatID:           cmd.ChatID,
+		Message:          reply.Text,
+		ParseMode:        "HTML",
+		MessageThreadID:  cmd.ThreadID,
+		ReplyToMessageID: cmd.MessageID,
+		Buttons:          reply.Buttons,
+	})
+	if err != nil {
+		log.Printf("❌ Не удалось ответить на команду /%s в чате %s: %v", cmd.Name, cmd.ChatID, err)
+	}
+}
+
+// answer подтверждает нажатие кнопки. Всплывающее уведомление - простой текст, без HTML.
+func (r *CommandRouter) answer(ctx context.Context, id, text string) {
+	if err := r.replier.AnswerCallbackQuery(ctx, id, plainText(text)); err != nil {
+		log.Printf("❌ %v", err)
+	}
+}
+
+func (r *CommandRouter) help(context.Context, Command) (CommandReply, error) {
+	var b strings.Builder
+	b.WriteString("Команды бота:")
+	for _, name := range r.order {
+		command := r.commands[name]
+		fmt.Fprintf(&b, "\n/%s — %s", name, html.EscapeString(command.description))
+		if command.admin {
+			b.WriteString(" (в группах — только администраторы)")
+		}
+	}
+	return CommandReply{Text: b.String()}, nil
+}
+
+// isGroupChat отличает группы и каналы (отрицательный ID) от личного чата с ботом
+func isGroupChat(chatID string) bool {
+	return strings.HasPrefix(chatID, "-")
+}
+
+func commandAuthor(cmd Command) string {
+	if cmd.Username != "" {
+		return "@" + cmd.Username
+	}
+	return fmt.Sprintf("%d", cmd.UserID)
+}
+
+// plainText убирает HTML-разметку ответа для всплывающего уведомления (до 200 символов)
+func plainText(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+
+	text := html.UnescapeString(b.String())
+	if runes := []rune(text); len(runes) > 200 {
+		text = string(runes[:199]) + "…"
+	}
+	return text
+}
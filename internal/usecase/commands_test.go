@@ -0,0 +1,265 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/telegram"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+)
+
+// fakeBotAPI - заглушка Bot API: отдаёт заданные обновления один раз и записывает ответы бота
+type fakeBotAPI struct {
+	mu       sync.Mutex
+	updates  []map[string]any
+	statuses map[int64]string
+	sent     []map[string]any
+	answers  []map[string]any
+	offsets  []int
+}
+
+func newFakeBotAPI(t *testing.T, updates ...map[string]any) (*fakeBotAPI, *telegram.Client) {
+	t.Helper()
+	api := &fakeBotAPI{updates: updates, statuses: make(map[int64]string)}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return api, telegram.NewClient(srv.URL, func() string { return "token" }, 5*time.Second, 0)
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result any = true
+	switch path.Base(r.URL.Path) {
+	case "getUpdates":
+		offset, _ := req["offset"].(float64)
+		f.offsets = append(f.offsets, int(offset))
+		result, f.updates = f.updates, nil
+		if result == nil {
+			result = []any{}
+		}
+	case "sendMessage":
+		f.sent = append(f.sent, req)
+		result = map[string]any{"message_id": len(f.sent)}
+	case "answerCallbackQuery":
+		f.answers = append(f.answers, req)
+	case "getChatMember":
+		status, ok := f.statuses[int64(req["user_id"].(float64))]
+		if !ok {
+			writeAPIResponse(w, map[string]any{"ok": false, "error_code": 400, "description": "Bad Request: user not found"})
+			return
+		}
+		result = map[string]any{"status": status}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	writeAPIResponse(w, map[string]any{"ok": true, "result": result})
+}
+
+func writeAPIResponse(w http.ResponseWriter, resp map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeBotAPI) sentTexts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	texts := make([]string, 0, len(f.sent))
+	for _, m := range f.sent {
+		texts = append(texts, m["text"].(string))
+	}
+	return texts
+}
+
+func message(chatID string, userID int64, text string) domain.Update {
+	return domain.Update{Message: &domain.IncomingMessage{ID: 1, ChatID: chatID, UserID: userID, Text: text}}
+}
+
+func TestCommandRouterAdminCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		chatID  string
+		userID  int64
+		wantRun bool
+		want    string
+	}{
+		{name: "creator", chatID: "-100", userID: 1, wantRun: true, want: "muted"},
+		{name: "administrator", chatID: "-100", userID: 2, wantRun: true, want: "muted"},
+		{name: "member", chatID: "-100", userID: 3, want: "только администраторам"},
+		{name: "status error", chatID: "-100", userID: 4, want: "Не удалось проверить права"},
+		{name: "private chat", chatID: "42", userID: 42, wantRun: true, want: "muted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, client := newFakeBotAPI(t)
+			api.statuses[1] = "creator"
+			api.statuses[2] = "administrator"
+			api.statuses[3] = "member"
+
+			router := usecase.NewCommandRouter(client, "")
+			ran := false
+			router.HandleAdmin("mute", "mute chat", func(context.Context, usecase.Command) (usecase.CommandReply, error) {
+				ran = true
+				return usecase.CommandReply{Text: "muted"}, nil
+			})
+
+			router.Dispatch(context.Background(), message(tt.chatID, tt.userID, "/mute"))
+
+			if ran != tt.wantRun {
+				t.Errorf("handler ran = %v, want %v", ran, tt.wantRun)
+			}
+			texts := api.sentTexts()
+			if len(texts) != 1 || !strings.Contains(texts[0], tt.want) {
+				t.Errorf("replies = %q, want one containing %q", texts, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandRouterHelpMarksAdminCommands(t *testing.T) {
+	api, client := newFakeBotAPI(t)
+	router := usecase.NewCommandRouter(client, "tgbot")
+	router.Handle("settings", "show settings", func(context.Context, usecase.Command) (usecase.CommandReply, error) {
+		return usecase.CommandReply{}, nil
+	})
+	router.HandleAdmin("mute", "mute chat", func(context.Context, usecase.Command) (usecase.CommandReply, error) {
+		return usecase.CommandReply{}, nil
+	})
+
+	// Команда другому боту в группе игнорируется
+	router.Dispatch(context.Background(), message("-100", 3, "/help@other_bot"))
+	// /help не требует прав администратора
+	router.Dispatch(context.Background(), message("-100", 3, "/help@tgbot"))
+
+	texts := api.sentTexts()
+	if len(texts) != 1 {
+		t.Fatalf("replies = %q, want exactly one", texts)
+	}
+	if !strings.Contains(texts[0], "/settings — show settings\n") {
+		t.Errorf("help = %q, want /settings without admin mark", texts[0])
+	}
+	if !strings.Contains(texts[0], "/mute — mute chat (в группах — только администраторы)") {
+		t.Errorf("help = %q, want /mute marked as admin command", texts[0])
+	}
+}
+
+// memoryOffsets - UpdateOffsetStore в памяти
+type memoryOffsets struct {
+	mu     sync.Mutex
+	offset int
+}
+
+func (m *memoryOffsets) UpdateOffset(context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.offset, nil
+}
+
+func (m *memoryOffsets) SetUpdateOffset(_ context.Context, offset int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offset = offset
+	return nil
+}
+
+func TestUpdatePollerDispatchesUpdates(t *testing.T) {
+	api, client := newFakeBotAPI(t,
+		map[string]any{
+			"update_id": 10,
+			"message": map[string]any{
+				"message_id": 5,
+				"from":       map[string]any{"id": 3, "username": "dev"},
+				"chat":       map[string]any{"id": -100},
+				"text":       "/help",
+			},
+		},
+		map[string]any{
+			"update_id": 11,
+			"callback_query": map[string]any{
+				"id":      "cb-1",
+				"from":    map[string]any{"id": 3, "username": "dev"},
+				"message": map[string]any{"message_id": 6, "chat": map[string]any{"id": -100}},
+				"data":    "mute 1h",
+			},
+		},
+	)
+	api.statuses[3] = "member"
+
+	router := usecase.NewCommandRouter(client, "")
+	muted := false
+	router.HandleAdmin("mute", "mute chat", func(context.Context, usecase.Command) (usecase.CommandReply, error) {
+		muted = true
+		return usecase.CommandReply{Text: "muted"}, nil
+	})
+
+	offsets := &memoryOffsets{offset: 7}
+	poller := usecase.NewUpdatePoller(client, offsets, router, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		poller.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		api.mu.Lock()
+		handled := len(api.sent) == 1 && len(api.answers) == 1
+		api.mu.Unlock()
+		if handled {
+			if offset, _ := offsets.UpdateOffset(ctx); offset == 12 {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("updates were not handled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poller did not stop after cancel")
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if api.offsets[0] != 7 {
+		t.Errorf("first getUpdates offset = %d, want saved offset 7", api.offsets[0])
+	}
+	if len(api.offsets) > 1 && api.offsets[1] != 12 {
+		t.Errorf("second getUpdates offset = %d, want 12", api.offsets[1])
+	}
+	if !strings.HasPrefix(api.sent[0]["text"].(string), "Команды бота:") || api.sent[0]["chat_id"] != "-100" {
+		t.Errorf("help reply = %v", api.sent[0])
+	}
+	// Кнопка под /mute от обычного участника не выполняет команду
+	if muted {
+		t.Error("mute callback from a member must be rejected")
+	}
+	if api.answers[0]["callback_query_id"] != "cb-1" || !strings.Contains(api.answers[0]["text"].(string), "только администраторам") {
+		t.Errorf("callback answer = %v", api.answers[0])
+	}
+}
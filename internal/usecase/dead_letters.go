@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/pkg/metrics"
+)
+
+var (
+	deadLetterCount atomic.Int64
+
+	_ = metrics.NewGaugeFunc(
+		"tgbot_dead_letters",
+		"Undelivered notifications in the dead-letter queue",
+		func() float64 { return float64(deadLetterCount.Load()) },
+	)
+)
+
+// DeadLetterStore - постоянное хранилище недоставленных уведомлений
+type DeadLetterStore interface {
+	Add(ctx context.Context, letter domain.DeadLetter) (int64, error)
+	// List возвращает сообщения, начиная с новых; пустой chatID - все чаты
+	List(ctx context.Context, chatID string, limit int) ([]domain.DeadLetter, error)
+	Get(ctx context.Context, id int64) (domain.DeadLetter, error)
+	Delete(ctx context.Context, id int64) error
+	// Purge удаляет сообщения чата (пустой chatID - все) и возвращает их количество
+	Purge(ctx context.Context, chatID string) (int, error)
+	// DeleteBefore удаляет сообщения, сохранённые раньше before, и возвращает их количество
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+	Count(ctx context.Context) (int, error)
+}
+
+// DeadLetters - очередь недоставленных уведомлений (их сохраняет DeadLetterSender):
+// просмотр, повторная отправка и удаление
+type DeadLetters struct {
+	store DeadLetterStore
+	// queue - очередь доставки для повторной отправки
+	queue MessageSender
+}
+
+func NewDeadLetters(ctx context.Context, store DeadLetterStore, queue MessageSender) (*DeadLetters, error) {
+	d := &DeadLetters{
+		store: store,
+		queue: queue,
+	}
+
+	if err := d.refreshCount(ctx); err != nil {
+		return nil, err
+	}
+	if n := deadLetterCount.Load(); n > 0 {
+		log.Printf("☠️ В очереди недоставленных сообщений: %d", n)
+	}
+
+	return d, nil
+}
+
+// DeadLetterSender оборачивает отправителя: уведомления, которые он не смог доставить,
+// сохраняются в очередь недоставленных. Ошибка отправки возвращается как есть.
+type DeadLetterSender struct {
+	next  MessageSender
+	store DeadLetterStore
+	now   func() time.Time
+}
+
+func NewDeadLetterSender(next MessageSender, store DeadLetterStore) *DeadLetterSender {
+	return &DeadLetterSender{
+		next:  next,
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (s *DeadLetterSender) SendMessage(ctx context.Context, n domain.Notification) error {
+	err := s.next.SendMessage(ctx, n)
+	if err == nil || ctx.Err() != nil || errors.Is(err, domain.ErrHeld) {
+		// Прерванная остановкой и отложенная на тихие часы отправка не считается недоставкой
+		return err
+	}
+
+	s.add(context.WithoutCancel(ctx), n, err)
+	return err
+}
+
+func (s *DeadLetterSender) add(ctx context.Context, n domain.Notification, cause error) {
+	attempts := 1
+	var deliveryErr *domain.DeliveryError
+	if errors.As(cause, &deliveryErr) {
+		attempts = deliveryErr.Attempts
+		cause = deliveryErr.Err
+	}
+
+	n.Done = nil
+	id, err := s.store.Add(ctx, domain.DeadLetter{
+		Notification: n,
+		Error:        cause.Error(),
+		Attempts:     attempts,
+		CreatedAt:    s.now(),
+	})
+	if err != nil {
+		log.Printf("❌ Недоставленное сообщение в чат %s потеряно: %v", n.ChatID, err)
+		return
+	}
+
+	deadLetterCount.Add(1)
+	log.Printf("☠️ Сообщение в чат %s сохранено в очередь недоставленных (#%d)", n.ChatID, id)
+}
+
+func (d *DeadLetters) List(ctx context.Context, chatID string, limit int) ([]domain.DeadLetter, error) {
+	return d.store.List(ctx, chatID, limit)
+}
+
+func (d *DeadLetters) Get(ctx context.Context, id int64) (domain.DeadLetter, error) {
+	return d.store.Get(ctx, id)
+}
+
+// Retry ставит сообщение в очередь доставки и убирает его из недоставленных.
+// Непустой chatID отправляет сообщение в другой чат. Если отправка снова не удастся,
+// сообщение вернётся в очередь недоставленных новой записью.
+func (d *DeadLetters) Retry(ctx context.Context, id int64, chatID string) (domain.DeadLetter, error) {
+	letter, err := d.store.Get(ctx, id)
+	if err != nil {
+		return letter, err
+	}
+
+	n := letter.Notification
+	if chatID != "" && chatID != n.ChatID {
+		// Тема форума, ветка и сообщение для ответа относятся к исходному чату
+		n.ChatID = chatID
+		n.MessageThreadID = 0
+		n.Thread = ""
+		n.ReplyToMessageID = 0
+	}
+
+	// Запись удаляется до постановки в очередь: из параллельных повторов одного сообщения
+	// удалить её сможет только один, остальные получат ErrDeadLetterNotFound
+	if err := d.Delete(ctx, id); err != nil {
+		return letter, err
+	}
+
+	if err := d.queue.SendMessage(ctx, n); err != nil {
+		err = fmt.Errorf("failed to enqueue dead letter %d: %w", id, err)
+		if _, restoreErr := d.store.Add(context.WithoutCancel(ctx), letter); restoreErr != nil {
+			log.Printf("❌ Недоставленное сообщение #%d в чат %s потеряно: %v", id, letter.Notification.ChatID, restoreErr)
+			return letter, err
+		}
+		deadLetterCount.Add(1)
+		return letter, err
+	}
+
+	log.Printf("🔁 Недоставленное сообщение #%d поставлено в очередь доставки в чат %s", id, n.ChatID)
+	letter.Notification = n
+	return letter, nil
+}
+
+func (d *DeadLetters) Delete(ctx context.Context, id int64) error {
+	if err := d.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	deadLetterCount.Add(-1)
+	return nil
+}
+
+// Purge удаляет недоставленные сообщения чата (пустой chatID - все)
+func (d *DeadLetters) Purge(ctx context.Context, chatID string) (int, error) {
+	n, err := d.store.Purge(ctx, chatID)
+	if err != nil {
+		return 0, err
+	}
+	deadLetterCount.Add(int64(-n))
+
+	log.Printf("🧹 Удалено недоставленных сообщений: %d", n)
+	return n, nil
+}
+
+// Expire удаляет сообщения, сохранённые раньше before (срок хранения)
+func (d *DeadLetters) Expire(ctx context.Context, before time.Time) (int, error) {
+	n, err := d.store.DeleteBefore(ctx, before)
+	deadLetterCount.Add(int64(-n))
+	return n, err
+}
+
+func (d *DeadLetters) refreshCount(ctx context.Context) error {
+	n, err := d.store.Count(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	deadLetterCount.Store(int64(n))
+	return nil
+}
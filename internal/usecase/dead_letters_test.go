@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+func newTestDeadLetters(t *testing.T, queue MessageSender, letters ...domain.DeadLetter) (*DeadLetters, *memoryDeadLetters) {
+	t.Helper()
+
+	store := &memoryDeadLetters{}
+	for _, letter := range letters {
+		if _, err := store.Add(context.Background(), letter); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := NewDeadLetters(context.Background(), store, queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, store
+}
+
+// threadedLetter - сообщение, отправленное ответом в ветку темы форума
+var threadedLetter = domain.DeadLetter{
+	Notification: domain.Notification{
+		ChatID:           "-100",
+		Message:          "pipeline failed",
+		MessageThreadID:  42,
+		Thread:           "gitlab/1/pipeline/7",
+		ReplyToMessageID: 15,
+	},
+	Error:     "telegram api error 400: message thread not found",
+	Attempts:  1,
+	CreatedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func TestDeadLettersRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		chatID string
+		want   domain.Notification
+	}{
+		{
+			name: "same chat keeps topic and thread",
+			want: threadedLetter.Notification,
+		},
+		{
+			name:   "same chat passed explicitly",
+			chatID: "-100",
+			want:   threadedLetter.Notification,
+		},
+		{
+			name:   "redirect drops chat-specific fields",
+			chatID: "-200",
+			want:   domain.Notification{ChatID: "-200", Message: "pipeline failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &recordingSender{}
+			d, store := newTestDeadLetters(t, queue, threadedLetter)
+
+			letter, err := d.Retry(context.Background(), 1, tt.chatID)
+			if err != nil {
+				t.Fatalf("Retry() error = %v", err)
+			}
+
+			if len(queue.sent) != 1 {
+				t.Fatalf("enqueued %d notifications, want 1", len(queue.sent))
+			}
+			got := queue.sent[0]
+			if got.ChatID != tt.want.ChatID || got.Message != tt.want.Message || got.MessageThreadID != tt.want.MessageThreadID ||
+				got.Thread != tt.want.Thread || got.ReplyToMessageID != tt.want.ReplyToMessageID {
+				t.Errorf("enqueued %+v, want %+v", got, tt.want)
+			}
+			if letter.Notification.ChatID != tt.want.ChatID {
+				t.Errorf("returned letter chat = %s, want %s", letter.Notification.ChatID, tt.want.ChatID)
+			}
+			if n, _ := store.Count(context.Background()); n != 0 {
+				t.Errorf("dead letters after retry = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestDeadLettersRetryRestoresLetterOnEnqueueFailure(t *testing.T) {
+	queue := &recordingSender{errs: []error{ErrQueueFull}}
+	d, store := newTestDeadLetters(t, queue, threadedLetter)
+
+	_, err := d.Retry(context.Background(), 1, "")
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Retry() error = %v, want ErrQueueFull", err)
+	}
+
+	// Сообщение возвращается новой записью с исходными данными
+	if _, err := store.Get(context.Background(), 1); !errors.Is(err, domain.ErrDeadLetterNotFound) {
+		t.Errorf("old record: Get() error = %v, want ErrDeadLetterNotFound", err)
+	}
+	restored, err := store.Get(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("restored record: %v", err)
+	}
+	if restored.Notification.ChatID != "-100" || restored.Notification.MessageThreadID != 42 || restored.Error != threadedLetter.Error {
+		t.Errorf("restored letter = %+v, want original letter", restored)
+	}
+}
+
+func TestDeadLettersConcurrentRetryEnqueuesOnce(t *testing.T) {
+	queue := &recordingSender{}
+	d, _ := newTestDeadLetters(t, queue, threadedLetter)
+
+	const retries = 10
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		ok       int
+		notFound int
+	)
+	for range retries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.Retry(context.Background(), 1, "-200")
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				ok++
+			case errors.Is(err, domain.ErrDeadLetterNotFound):
+				notFound++
+			default:
+				t.Errorf("Retry() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ok != 1 || notFound != retries-1 {
+		t.Errorf("successful retries = %d, not found = %d, want 1 and %d", ok, notFound, retries-1)
+	}
+	if len(queue.sent) != 1 {
+		t.Errorf("enqueued %d notifications, want 1", len(queue.sent))
+	}
+}
+
+func TestDeadLetterSenderStoresOnlyDeliveryFailures(t *testing.T) {
+	failure := &domain.DeliveryError{ChatID: "-1", Attempts: 3, Err: errors.New("bad gateway")}
+
+	tests := []struct {
+		name      string
+		err       error
+		cancel    bool
+		wantSaved bool
+	}{
+		{name: "delivered"},
+		{name: "delivery failure", err: failure, wantSaved: true},
+		{name: "held until end of quiet hours", err: domain.ErrHeld},
+		{name: "interrupted by shutdown", err: failure, cancel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryDeadLetters{}
+			s := NewDeadLetterSender(&recordingSender{errs: []error{tt.err}}, store)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			if err := s.SendMessage(ctx, domain.Notification{ChatID: "-1", Message: "text"}); !errors.Is(err, tt.err) {
+				t.Errorf("SendMessage() error = %v, want %v", err, tt.err)
+			}
+
+			if saved := len(store.letters) == 1; saved != tt.wantSaved {
+				t.Fatalf("saved = %v, want %v", saved, tt.wantSaved)
+			}
+			if tt.wantSaved && (store.letters[0].Attempts != 3 || store.letters[0].Error != "bad gateway") {
+				t.Errorf("saved letter = %+v, want attempts and cause from DeliveryError", store.letters[0])
+			}
+		})
+	}
+}
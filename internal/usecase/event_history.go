@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// EventStore - постоянное хранилище истории событий
+type EventStore interface {
+	Add(ctx context.Context, record domain.EventRecord) (int64, error)
+	// Finish сохраняет итог доставки
+	Finish(ctx context.Context, id int64, outcome domain.EventOutcome, deliveries []domain.EventDelivery) error
+	// Query возвращает записи по фильтру, начиная с новых
+	Query(ctx context.Context, filter domain.EventFilter) ([]domain.EventRecord, error)
+	// FindByOutbox возвращает запись о событии из записи outbox (domain.ErrEventNotFound, если её нет)
+	FindByOutbox(ctx context.Context, outboxID int64) (domain.EventRecord, error)
+}
+
+// EventHistory записывает каждое обработанное событие вместе с решениями маршрутизации
+// и результатом отправки в каждый чат, чтобы можно было проверить, почему уведомление не пришло
+type EventHistory struct {
+	store EventStore
+	now   func() time.Time
+}
+
+func NewEventHistory(store EventStore) *EventHistory {
+	return &EventHistory{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (h *EventHistory) Query(ctx context.Context, filter domain.EventFilter) ([]domain.EventRecord, error) {
+	return h.store.Query(ctx, filter)
+}
+
+// begin сохраняет событие с решениями маршрутизации. Ошибка хранилища не мешает доставке:
+// запись пропускается, а begin возвращает nil. Повторная попытка доставки события из outbox
+// (outboxID > 0) обновляет запись первой попытки, а не добавляет новую.
+func (h *EventHistory) begin(ctx context.Context, event *domain.Event, decisions []RouteDecision, outboxID int64) *eventTracking {
+	record := domain.NewEventRecord(event, h.now())
+	record.OutboxID = outboxID
+	for _, decision := range decisions {
+		record.Deliveries = append(record.Deliveries, domain.EventDelivery{
+			ChatID:  decision.Repository.TelegramChatID,
+			Matched: decision.Matched,
+			Reason:  decision.Reason,
+		})
+	}
+
+	// Итог доставки сохраняется из воркеров очереди, в том числе во время остановки
+	ctx = context.WithoutCancel(ctx)
+
+	if outboxID > 0 {
+		if tracking, ok := h.resume(ctx, record); ok {
+			return tracking
+		}
+	}
+
+	id, err := h.store.Add(ctx, record)
+	if err != nil {
+		log.Printf("❌ Не удалось сохранить событие %s проекта %s в историю: %v", event.Kind, event.Key(), err)
+		return nil
+	}
+
+	return &eventTracking{ctx: ctx, store: h.store, id: id, deliveries: record.Deliveries}
+}
+
+// resume продолжает запись истории о событии из outbox, сохранённую прошлой попыткой доставки.
+// Для чатов, в которые уведомление было отправлено раньше, сохраняется прошлый результат.
+func (h *EventHistory) resume(ctx context.Context, record domain.EventRecord) (*eventTracking, bool) {
+	prev, err := h.store.FindByOutbox(ctx, record.OutboxID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrEventNotFound) {
+			log.Printf("❌ Не удалось прочитать историю события outbox #%d: %v", record.OutboxID, err)
+		}
+		return nil, false
+	}
+
+	for i, delivery := range record.Deliveries {
+		if delivery.Matched {
+			continue
+		}
+		for _, old := range prev.Deliveries {
+			if old.ChatID == delivery.ChatID && old.Matched && old.Done {
+				record.Deliveries[i] = old
+				break
+			}
+		}
+	}
+
+	if err := h.store.Finish(ctx, prev.ID, domain.OutcomePending, record.Deliveries); err != nil {
+		log.Printf("❌ Не удалось обновить событие #%d в истории: %v", prev.ID, err)
+		return nil, false
+	}
+
+	return &eventTracking{ctx: ctx, store: h.store, id: prev.ID, deliveries: record.Deliveries}, true
+}
+
+// eventTracking собирает результаты отправки уведомлений события и сохраняет итог,
+// когда все уведомления обработаны. Уведомления могут быть обработаны раньше, чем
+// станет известно, какие из них приняты очередью.
+type eventTracking struct {
+	ctx   context.Context
+	store EventStore
+	id    int64
+
+	mu         sync.Mutex
+	deliveries []domain.EventDelivery
+	sealed     bool
+	finished   bool
+}
+
+// track возвращает обработчик результата отправки по маршруту i, который также вызывает done
+func (t *eventTracking) track(i int, done func(error)) func(error) {
+	if t == nil {
+		return done
+	}
+
+	return func(err error) {
+		t.mu.Lock()
+		t.deliveries[i].Done = true
+		switch {
+		case errors.Is(err, domain.ErrHeld):
+			t.deliveries[i].Held = true
+		case err != nil:
+			t.deliveries[i].Error = err.Error()
+		}
+		t.mu.Unlock()
+
+		t.finish()
+
+		if done != nil {
+			done(err)
+		}
+	}
+}
+
+// seal отмечает маршруты, уведомления по которым не удалось передать отправителю.
+// После seal итог сохраняется, как только завершатся все принятые уведомления.
+func (t *eventTracking) seal(failed map[int]error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	for i, err := range failed {
+		t.deliveries[i].Done = true
+		t.deliveries[i].Error = err.Error()
+	}
+	t.sealed = true
+	t.mu.Unlock()
+
+	t.finish()
+}
+
+func (t *eventTracking) finish() {
+	t.mu.Lock()
+	if !t.sealed || t.finished {
+		t.mu.Unlock()
+		return
+	}
+
+	var matched, failed, held int
+	for _, d := range t.deliveries {
+		if !d.Matched {
+			continue
+		}
+		if !d.Done {
+			t.mu.Unlock()
+			return
+		}
+		matched++
+		if d.Error != "" {
+			failed++
+		}
+		if d.Held {
+			held++
+		}
+	}
+	t.finished = true
+
+	outcome := domain.OutcomeDelivered
+	switch {
+	case matched == 0:
+		outcome = domain.OutcomeSkipped
+	case failed == matched:
+		outcome = domain.OutcomeFailed
+	case failed > 0:
+		outcome = domain.OutcomePartial
+	case held > 0:
+		outcome = domain.OutcomeHeld
+	}
+	deliveries := append([]domain.EventDelivery(nil), t.deliveries...)
+	t.mu.Unlock()
+
+	if err := t.store.Finish(t.ctx, t.id, outcome, deliveries); err != nil {
+		log.Printf("❌ Не удалось сохранить итог доставки события #%d: %v", t.id, err)
+	}
+}
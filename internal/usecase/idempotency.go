@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/pkg/metrics"
+)
+
+// Результаты проверки ключа идемпотентности (значения метки result метрики)
+const (
+	DeliveryNew        = "new"
+	DeliveryDuplicate  = "duplicate"
+	DeliveryInProgress = "in_progress"
+	DeliveryNoKey      = "no_key"
+)
+
+var (
+	webhookDeliveries = metrics.NewCounterVec(
+		"tgbot_webhook_deduplication_total",
+		"Webhook deliveries by idempotency check result",
+		"result",
+	)
+
+	idempotencyEntries atomic.Int64
+
+	_ = metrics.NewGaugeFunc(
+		"tgbot_webhook_idempotency_keys",
+		"Webhook idempotency keys currently remembered",
+		func() float64 { return float64(idempotencyEntries.Load()) },
+	)
+)
+
+// Idempotency запоминает ключи обработанных вебхуков (X-Gitlab-Event-UUID, Idempotency-Key)
+// на время ttl, чтобы повторы GitLab и балансировщика не приводили к повторной отправке в чат.
+// Ключи хранятся в памяти; при превышении maxEntries вытесняются самые старые.
+type Idempotency struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+	// order - ключи в порядке добавления. Release не удаляет ключ из order: слот,
+	// номер которого не совпадает с номером текущей записи ключа, устарел и пропускается.
+	order      []idempotencySlot
+	seq        uint64
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+type idempotencyEntry struct {
+	expiresAt time.Time
+	done      bool
+	seq       uint64
+}
+
+type idempotencySlot struct {
+	key string
+	seq uint64
+}
+
+// current проверяет, что слот order относится к текущей записи ключа
+func (s *Idempotency) current(slot idempotencySlot) (idempotencyEntry, bool) {
+	entry, ok := s.entries[slot.key]
+	return entry, ok && entry.seq == slot.seq
+}
+
+func NewIdempotency(ttl time.Duration, maxEntries int) *Idempotency {
+	return &Idempotency{
+		entries:    make(map[string]idempotencyEntry),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Begin проверяет ключ и возвращает DeliveryNew, если вебхук нужно обработать.
+// Для DeliveryNew вызывающий обязан затем вызвать Complete или Release.
+// Пустой ключ не проверяется (DeliveryNoKey).
+func (s *Idempotency) Begin(key string) string {
+	result := s.begin(key)
+	webhookDeliveries.Inc(result)
+	return result
+}
+
+func (s *Idempotency) begin(key string) string {
+	if key == "" {
+		return DeliveryNoKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.expire(now)
+
+	if entry, ok := s.entries[key]; ok {
+		if entry.done {
+			return DeliveryDuplicate
+		}
+		return DeliveryInProgress
+	}
+
+	s.seq++
+	s.entries[key] = idempotencyEntry{expiresAt: now.Add(s.ttl), seq: s.seq}
+	s.order = append(s.order, idempotencySlot{key: key, seq: s.seq})
+	s.evict()
+	idempotencyEntries.Store(int64(len(s.entries)))
+
+	return DeliveryNew
+}
+
+// Complete отмечает вебхук обработанным: повторы с этим ключом до истечения ttl
+// (отсчитывается от первого получения) подтверждаются без отправки
+func (s *Idempotency) Complete(key string) {
+	if key == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		entry.done = true
+		s.entries[key] = entry
+	}
+}
+
+// Release забывает ключ после неудачной обработки, чтобы повтор GitLab был обработан заново
+func (s *Idempotency) Release(key string) {
+	if key == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	idempotencyEntries.Store(int64(len(s.entries)))
+}
+
+// expire удаляет истёкшие ключи. order упорядочен по времени добавления, а ttl у всех ключей
+// одинаковый, поэтому достаточно просмотреть начало списка.
+func (s *Idempotency) expire(now time.Time) {
+	i := 0
+	for ; i < len(s.order); i++ {
+		slot := s.order[i]
+		entry, ok := s.current(slot)
+		if ok && now.Before(entry.expiresAt) {
+			break
+		}
+		if ok {
+			delete(s.entries, slot.key)
+		}
+	}
+	s.order = s.order[i:]
+	idempotencyEntries.Store(int64(len(s.entries)))
+}
+
+// evict вытесняет самые старые ключи сверх maxEntries
+func (s *Idempotency) evict() {
+	for len(s.entries) > s.maxEntries && len(s.order) > 0 {
+		if _, ok := s.current(s.order[0]); ok {
+			delete(s.entries, s.order[0].key)
+		}
+		s.order = s.order[1:]
+	}
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+// idempotencyStep - действие с ключом; для begin проверяется результат want
+type idempotencyStep struct {
+	op      string // begin, complete, release, wait
+	key     string
+	wait    time.Duration
+	want    string
+	comment string
+}
+
+func TestIdempotency(t *testing.T) {
+	const ttl = time.Hour
+
+	tests := []struct {
+		name       string
+		maxEntries int
+		steps      []idempotencyStep
+	}{
+		{
+			name:       "empty key is not checked",
+			maxEntries: 10,
+			steps: []idempotencyStep{
+				{op: "begin", key: "", want: DeliveryNoKey},
+				{op: "begin", key: "", want: DeliveryNoKey},
+			},
+		},
+		{
+			name:       "duplicate within ttl",
+			maxEntries: 10,
+			steps: []idempotencyStep{
+				{op: "begin", key: "a", want: DeliveryNew},
+				{op: "begin", key: "a", want: DeliveryInProgress, comment: "first delivery is still processed"},
+				{op: "complete", key: "a"},
+				{op: "wait", wait: ttl - time.Second},
+				{op: "begin", key: "a", want: DeliveryDuplicate},
+			},
+		},
+		{
+			name:       "duplicate after expiry",
+			maxEntries: 10,
+			steps: []idempotencyStep{
+				{op: "begin", key: "a", want: DeliveryNew},
+				{op: "complete", key: "a"},
+				{op: "wait", wait: ttl},
+				{op: "begin", key: "a", want: DeliveryNew, comment: "ttl counts from the first delivery"},
+			},
+		},
+		{
+			name:       "released key is processed again",
+			maxEntries: 10,
+			steps: []idempotencyStep{
+				{op: "begin", key: "a", want: DeliveryNew},
+				{op: "release", key: "a"},
+				{op: "begin", key: "a", want: DeliveryNew},
+			},
+		},
+		{
+			name:       "eviction at capacity",
+			maxEntries: 2,
+			steps: []idempotencyStep{
+				{op: "begin", key: "a", want: DeliveryNew},
+				{op: "complete", key: "a"},
+				{op: "begin", key: "b", want: DeliveryNew},
+				{op: "complete", key: "b"},
+				{op: "begin", key: "c", want: DeliveryNew},
+				{op: "complete", key: "c"},
+				{op: "begin", key: "b", want: DeliveryDuplicate},
+				{op: "begin", key: "c", want: DeliveryDuplicate},
+				{op: "begin", key: "a", want: DeliveryNew, comment: "oldest key is evicted"},
+			},
+		},
+		{
+			name:       "stale slot of a released key does not evict it",
+			maxEntries: 2,
+			steps: []idempotencyStep{
+				{op: "begin", key: "a", want: DeliveryNew},
+				{op: "release", key: "a"},
+				{op: "begin", key: "b", want: DeliveryNew},
+				{op: "complete", key: "b"},
+				{op: "begin", key: "a", want: DeliveryNew},
+				{op: "complete", key: "a"},
+				// В order: a (устаревший), b, a. Вытесняется b, а не новая запись a
+				{op: "begin", key: "c", want: DeliveryNew},
+				{op: "begin", key: "a", want: DeliveryDuplicate},
+				{op: "begin", key: "b", want: DeliveryNew, comment: "b is the oldest current key"},
+			},
+		},
+		{
+			name:       "stale slot of a released key does not expire it",
+			maxEntries: 10,
+			steps: []idempotencyStep{
+				{op: "begin", key: "a", want: DeliveryNew},
+				{op: "release", key: "a"},
+				{op: "wait", wait: ttl / 2},
+				{op: "begin", key: "a", want: DeliveryNew},
+				{op: "complete", key: "a"},
+				{op: "wait", wait: ttl / 2},
+				{op: "begin", key: "a", want: DeliveryDuplicate, comment: "ttl counts from the second begin"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+			s := NewIdempotency(ttl, tt.maxEntries)
+			s.now = func() time.Time { return now }
+
+			for i, step := range tt.steps {
+				switch step.op {
+				case "begin":
+					if got := s.Begin(step.key); got != step.want {
+						t.Fatalf("step %d: Begin(%q) = %s, want %s %s", i, step.key, got, step.want, step.comment)
+					}
+				case "complete":
+					s.Complete(step.key)
+				case "release":
+					s.Release(step.key)
+				case "wait":
+					now = now.Add(step.wait)
+				}
+			}
+
+			if len(s.entries) > tt.maxEntries {
+				t.Errorf("entries = %d, want at most %d", len(s.entries), tt.maxEntries)
+			}
+		})
+	}
+}
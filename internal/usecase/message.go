@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// Ограничения частей уведомления: вместе с заголовком текст должен укладываться в 4096 символов
+// (лимит Telegram), иначе сообщение отклоняется с ошибкой 400 и попадает в недоставленные
+const (
+	// maxListedFiles - максимальное количество файлов в тексте уведомления
+	maxListedFiles = 20
+	// maxCommitMessageLength - сколько символов сообщения коммита выводится в уведомлении
+	maxCommitMessageLength = 800
+	// maxFilePathLength - сколько символов пути файла выводится в списке файлов
+	maxFilePathLength = 100
+)
+
+// messageTexts - переводимые части текста уведомления
+type messageTexts struct {
+	in      string
+	commits string
+	files   string
+	more    string
+	// held - заголовок пачки сообщений, отложенных на тихие часы
+	held string
+}
+
+var messageLanguages = map[string]messageTexts{
+	domain.LanguageRussian: {
+		in: "в", commits: "Коммитов", files: "Файлы", more: "… и ещё %d",
+		held: "🌙 <b>Уведомления за время тишины (%d)</b>",
+	},
+	domain.LanguageEnglish: {
+		in: "in", commits: "Commits", files: "Files", more: "… and %d more",
+		held: "🌙 <b>Notifications during quiet hours (%d)</b>",
+	},
+}
+
+// renderMessage формирует HTML-текст уведомления в зависимости от типа события
+// на языке и с подробностью из настроек чата
+func renderMessage(event *domain.Event, files []string, settings domain.ChatSettings) string {
+	texts := messageLanguages[settings.LanguageOrDefault()]
+	verbosity := settings.VerbosityOrDefault()
+
+	if verbosity == domain.VerbosityMinimal {
+		return renderMinimalMessage(event, texts)
+	}
+
+	var text string
+	switch event.Kind {
+	case domain.EventKindPipeline:
+		text = renderPipelineMessage(event, texts)
+	case domain.EventKindMergeRequest:
+		text = renderMergeRequestMessage(event, texts)
+	case domain.EventKindIssue:
+		text = renderIssueMessage(event, texts)
+	case domain.EventKindNote:
+		text = renderNoteMessage(event, texts)
+	default:
+		text = renderPushMessage(event.Push, files, texts)
+	}
+
+	if verbosity == domain.VerbosityDetailed && !event.Timestamp.IsZero() {
+		text += "\n🕒 " + event.Timestamp.In(settings.Location()).Format("2006-01-02 15:04 MST")
+	}
+
+	return text
+}
+
+// renderMinimalMessage формирует уведомление в одну строку
+func renderMinimalMessage(event *domain.Event, texts messageTexts) string {
+	project := html.EscapeString(event.ProjectName)
+
+	switch event.Kind {
+	case domain.EventKindPipeline:
+		return fmt.Sprintf("%s <a href=\"%s\">Pipeline #%d</a> %s %s (<code>%s</code>): <b>%s</b>",
+			pipelineIcon(event.Status), html.EscapeString(event.URL), event.ObjectID, texts.in, project,
+			html.EscapeString(event.Ref), html.EscapeString(event.Status))
+	case domain.EventKindMergeRequest:
+		text := fmt.Sprintf("🔀 <a href=\"%s\">!%d</a> %s %s", html.EscapeString(event.URL), event.ObjectID, texts.in, project)
+		if event.Action != "" {
+			text += fmt.Sprintf(": <b>%s</b>", html.EscapeString(event.Action))
+		}
+		return text + " — " + html.EscapeString(event.Title)
+	case domain.EventKindIssue:
+		text := fmt.Sprintf("📌 <a href=\"%s\">#%d</a> %s %s", html.EscapeString(event.URL), event.ObjectID, texts.in, project)
+		if event.Action != "" {
+			text += fmt.Sprintf(": <b>%s</b>", html.EscapeString(event.Action))
+		}
+		return text + " — " + html.EscapeString(event.Title)
+	case domain.EventKindNote:
+		return fmt.Sprintf("💬 <a href=\"%s\">%s</a> %s %s: %s", html.EscapeString(event.URL),
+			html.EscapeString(noteTarget(event)), texts.in, project, html.EscapeString(firstLine(event.Note)))
+	}
+
+	push := event.Push
+	return fmt.Sprintf("🚀 <a href=\"%s\">%s</a> %s %s (<code>%s</code>): %s",
+		html.EscapeString(push.CommitURL), html.EscapeString(shortHash(push.CommitHash)), texts.in,
+		html.EscapeString(push.RepositoryName), html.EscapeString(push.Branch),
+		html.EscapeString(firstLine(push.CommitMsg)))
+}
+
+// pipelineStatusIcons - иконки статусов пайплайна
+var pipelineStatusIcons = map[string]string{
+	"success":  "✅",
+	"failed":   "❌",
+	"canceled": "🚫",
+	"running":  "🔄",
+	"pending":  "⏳",
+	"skipped":  "⏭️",
+}
+
+func pipelineIcon(status string) string {
+	if icon, ok := pipelineStatusIcons[status]; ok {
+		return icon
+	}
+	return "⚙️"
+}
+
+func renderPipelineMessage(event *domain.Event, texts messageTexts) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s <b>Pipeline</b> <a href=\"%s\">#%d</a> %s <a href=\"%s\">%s</a> (<code>%s</code>): <b>%s</b>\n",
+		pipelineIcon(event.Status),
+		html.EscapeString(event.URL), event.ObjectID, texts.in,
+		html.EscapeString(event.ProjectURL),
+		html.EscapeString(event.ProjectName),
+		html.EscapeString(event.Ref),
+		html.EscapeString(event.Status))
+	fmt.Fprintf(&b, "👤 %s", html.EscapeString(event.Author))
+
+	if event.Title != "" {
+		fmt.Fprintf(&b, "\n📝 %s", html.EscapeString(event.Title))
+	}
+
+	return b.String()
+}
+
+func renderMergeRequestMessage(event *domain.Event, texts messageTexts) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "🔀 <b>Merge request</b> <a href=\"%s\">!%d</a> %s <a href=\"%s\">%s</a>",
+		html.EscapeString(event.URL), event.ObjectID, texts.in,
+		html.EscapeString(event.ProjectURL),
+		html.EscapeString(event.ProjectName))
+
+	if event.Action != "" {
+		fmt.Fprintf(&b, ": <b>%s</b>", html.EscapeString(event.Action))
+	}
+
+	fmt.Fprintf(&b, "\n📝 %s\n", html.EscapeString(event.Title))
+	fmt.Fprintf(&b, "🎯 <code>%s</code>\n", html.EscapeString(event.Ref))
+	fmt.Fprintf(&b, "👤 %s", html.EscapeString(event.Author))
+
+	return b.String()
+}
+
+func renderIssueMessage(event *domain.Event, texts messageTexts) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "📌 <b>Issue</b> <a href=\"%s\">#%d</a> %s <a href=\"%s\">%s</a>",
+		html.EscapeString(event.URL), event.ObjectID, texts.in,
+		html.EscapeString(event.ProjectURL),
+		html.EscapeString(event.ProjectName))
+
+	if event.Action != "" {
+		fmt.Fprintf(&b, ": <b>%s</b>", html.EscapeString(event.Action))
+	}
+
+	fmt.Fprintf(&b, "\n📝 %s\n", html.EscapeString(event.Title))
+	fmt.Fprintf(&b, "👤 %s", html.EscapeString(event.Author))
+
+	return b.String()
+}
+
+// maxNoteLength - сколько символов комментария выводится в уведомлении
+const maxNoteLength = 500
+
+func renderNoteMessage(event *domain.Event, texts messageTexts) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "💬 <b>Comment</b> <a href=\"%s\">%s</a> %s <a href=\"%s\">%s</a>",
+		html.EscapeString(event.URL), html.EscapeString(noteTarget(event)), texts.in,
+		html.EscapeString(event.ProjectURL),
+		html.EscapeString(event.ProjectName))
+
+	if event.Title != "" {
+		fmt.Fprintf(&b, "\n📝 %s", html.EscapeString(event.Title))
+	}
+	fmt.Fprintf(&b, "\n👤 %s", html.EscapeString(event.Author))
+
+	fmt.Fprintf(&b, "\n\n%s", html.EscapeString(truncate(event.Note, maxNoteLength)))
+
+	return b.String()
+}
+
+// noteTarget возвращает обозначение объекта, к которому оставлен комментарий: !12, #34 или тип объекта
+func noteTarget(event *domain.Event) string {
+	switch {
+	case event.MergeRequestIID > 0:
+		return fmt.Sprintf("!%d", event.MergeRequestIID)
+	case event.IssueIID > 0:
+		return fmt.Sprintf("#%d", event.IssueIID)
+	case event.Action != "":
+		return event.Action
+	}
+	return "note"
+}
+
+// renderPushMessage формирует HTML-текст уведомления о push-событии.
+// Если files не пустой, в сообщение добавляется список файлов.
+func renderPushMessage(event *domain.CommitEvent, files []string, texts messageTexts) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "🚀 <b>Push</b> %s <a href=\"%s\">%s</a> (<code>%s</code>)\n",
+		texts.in,
+		html.EscapeString(event.WebURL),
+		html.EscapeString(event.RepositoryName),
+		html.EscapeString(event.Branch))
+	fmt.Fprintf(&b, "👤 %s\n", html.EscapeString(event.Author))
+
+	if event.CommitsCount > 1 {
+		fmt.Fprintf(&b, "📦 %s: %d\n", texts.commits, event.CommitsCount)
+	}
+
+	fmt.Fprintf(&b, "📝 <a href=\"%s\">%s</a>: %s",
+		html.EscapeString(event.CommitURL),
+		html.EscapeString(shortHash(event.CommitHash)),
+		html.EscapeString(truncate(strings.TrimSpace(event.CommitMsg), maxCommitMessageLength)))
+
+	if len(files) > 0 {
+		fmt.Fprintf(&b, "\n\n📂 <b>%s:</b>", texts.files)
+		for i, file := range files {
+			if i == maxListedFiles {
+				b.WriteString("\n")
+				fmt.Fprintf(&b, texts.more, len(files)-maxListedFiles)
+				break
+			}
+			fmt.Fprintf(&b, "\n• <code>%s</code>", html.EscapeString(truncate(file, maxFilePathLength)))
+		}
+	}
+
+	return b.String()
+}
+
+// truncate обрезает s до limit символов, отмечая обрезку многоточием
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
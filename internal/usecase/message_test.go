@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// visibleLength - длина текста так, как её считает Telegram: без HTML-разметки
+func visibleLength(text string) int {
+	return utf8.RuneCountInString(html.UnescapeString(htmlTag.ReplaceAllString(text, "")))
+}
+
+func TestRenderPushMessageFitsTelegramLimit(t *testing.T) {
+	files := make([]string, 50)
+	for i := range files {
+		files[i] = strings.Repeat("very/long/directory/", 20) + "file.go"
+	}
+	name := strings.Repeat("repository-", 20)
+	event := &domain.CommitEvent{
+		RepositoryName: name,
+		Branch:         strings.Repeat("feature/", 30),
+		Author:         strings.Repeat("Author ", 30),
+		CommitHash:     "0123456789abcdef",
+		CommitMsg:      strings.Repeat("Очень длинное сообщение коммита & <теги>. ", 300),
+		WebURL:         "https://gitlab.example.com/" + name,
+		CommitURL:      "https://gitlab.example.com/" + name + "/-/commit/0123456789abcdef",
+		CommitsCount:   3,
+	}
+
+	for lang, texts := range messageLanguages {
+		text := renderPushMessage(event, files, texts)
+		if n := visibleLength(text); n > 4096 {
+			t.Errorf("%s: message length = %d, want at most 4096", lang, n)
+		}
+		if !strings.Contains(text, "…") {
+			t.Errorf("%s: truncated commit message must end with an ellipsis", lang)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in    string
+		limit int
+		want  string
+	}{
+		{in: "short", limit: 10, want: "short"},
+		{in: "exact", limit: 5, want: "exact"},
+		{in: "обрезать", limit: 3, want: "обр…"},
+		{in: "", limit: 3, want: ""},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.limit); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.limit, got, tt.want)
+		}
+	}
+}
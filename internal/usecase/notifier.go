@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// MessageSender - интерфейс для отправки уведомлений в Telegram
+type MessageSender interface {
+	SendMessage(ctx context.Context, n domain.Notification) error
+}
+
+// Notifier сопоставляет события GitLab с маршрутами из конфигурации
+// и отправляет уведомления в соответствующие чаты
+type Notifier struct {
+	// routing заменяется целиком при перезагрузке конфигурации
+	routing  atomic.Pointer[RoutingTable]
+	priority *PriorityPolicy
+	unknown  *UnknownProjects
+	// history - nil, если история событий не ведётся
+	history *EventHistory
+	// chats - nil, если настройки чатов не хранятся (действуют значения по умолчанию)
+	chats  *ChatSettings
+	sender MessageSender
+}
+
+func NewNotifier(routing *RoutingTable, priority *PriorityPolicy, unknown *UnknownProjects, history *EventHistory, chats *ChatSettings, sender MessageSender) *Notifier {
+	n := &Notifier{
+		priority: priority,
+		unknown:  unknown,
+		history:  history,
+		chats:    chats,
+		sender:   sender,
+	}
+	n.routing.Store(routing)
+
+	return n
+}
+
+// Routing возвращает текущую таблицу маршрутизации
+func (n *Notifier) Routing() *RoutingTable {
+	return n.routing.Load()
+}
+
+// SetRouting атомарно заменяет таблицу маршрутизации
+func (n *Notifier) SetRouting(routing *RoutingTable) {
+	n.routing.Store(routing)
+}
+
+// WebhookSecrets возвращает действующие секреты вебхука проекта по текущей таблице маршрутизации
+func (n *Notifier) WebhookSecrets(instance, projectID string) ([]string, bool) {
+	return n.Routing().WebhookSecrets(domain.ProjectKey{Instance: instance, ProjectID: projectID}, time.Now())
+}
+
+// Explain объясняет решения маршрутизации события по текущей таблице с учётом настроек чатов
+func (n *Notifier) Explain(event *domain.Event) []RouteDecision {
+	return n.explain(n.Routing(), event, time.Now())
+}
+
+// explain дополняет решения таблицы маршрутизации настройками чатов: в заглушённый чат не отправляется.
+// В отличие от тихих часов уведомления не откладываются: после снятия заглушения они не придут.
+func (n *Notifier) explain(routing *RoutingTable, event *domain.Event, now time.Time) []RouteDecision {
+	decisions := routing.Explain(event)
+	for i, decision := range decisions {
+		if !decision.Matched {
+			continue
+		}
+		if settings := n.chats.Get(decision.Repository.TelegramChatID); settings.Muted(now) {
+			decisions[i].Matched = false
+			decisions[i].Reason = "chat is muted until " + settings.MutedUntil.Format(time.RFC3339)
+		}
+	}
+	return decisions
+}
+
+// Notify отправляет уведомление о событии во все подходящие чаты
+// и возвращает количество отправленных сообщений
+func (n *Notifier) Notify(ctx context.Context, event *domain.Event) (int, error) {
+	accepted, err := n.NotifyTracked(ctx, 0, event, nil, nil)
+	return len(accepted), err
+}
+
+// NotifyTracked работает как Notify, но пропускает чаты из delivered (уведомления в них приняты
+// в прошлой попытке) и возвращает чаты, уведомления в которые приняты отправителем. done вызывается
+// с чатом каждого принятого уведомления после попытки доставки (см. domain.Notification.Done).
+// outboxID - запись outbox события (0 - без outbox): все попытки её доставки пишутся в одну запись истории.
+func (n *Notifier) NotifyTracked(ctx context.Context, outboxID int64, event *domain.Event, delivered []string, done func(chatID string, err error)) ([]string, error) {
+	var (
+		accepted []string
+		errs     []error
+	)
+
+	// Одна таблица на всё событие, даже если конфигурация перезагрузится во время обработки
+	routing := n.Routing()
+
+	if !routing.Known(event.Key()) {
+		log.Printf("❓ Событие %s от неизвестного проекта %s (%s)", event.Kind, event.Key(), event.ProjectName)
+		n.unknown.Record(event)
+	}
+
+	priority := n.priority.Resolve(event)
+
+	decisions := n.explain(routing, event, time.Now())
+	for i, decision := range decisions {
+		if decision.Matched && slices.Contains(delivered, decision.Repository.TelegramChatID) {
+			decisions[i].Matched = false
+			decisions[i].Reason = "notification was accepted in a previous attempt"
+		}
+	}
+
+	var tracking *eventTracking
+	if n.history != nil {
+		tracking = n.history.begin(ctx, event, decisions, outboxID)
+	}
+	failed := make(map[int]error)
+	defer func() { tracking.seal(failed) }()
+
+	for i, decision := range decisions {
+		repo := decision.Repository
+
+		if !decision.Matched {
+			log.Printf("⏭️ Событие %s в %s пропущено для чата %s: %s",
+				event.Kind, event.ProjectName, repo.TelegramChatID, decision.Reason)
+			continue
+		}
+
+		settings := n.chats.Get(repo.TelegramChatID)
+
+		var files []string
+		switch settings.VerbosityOrDefault() {
+		case domain.VerbosityDetailed:
+			files = decision.Files
+		case domain.VerbosityNormal:
+			if repo.ShowFiles {
+				files = decision.Files
+			}
+		}
+
+		var chatDone func(error)
+		if done != nil {
+			chatID := repo.TelegramChatID
+			chatDone = func(err error) { done(chatID, err) }
+		}
+
+		notification := domain.Notification{
+			ChatID:          repo.TelegramChatID,
+			Message:         renderMessage(event, files, settings),
+			ParseMode:       "HTML",
+			MessageThreadID: settings.DefaultThread,
+			Thread:          event.Thread(),
+			Done:            tracking.track(i, chatDone),
+		}
+		n.priority.Apply(&notification, priority)
+
+		if err := n.sender.SendMessage(ctx, notification); err != nil {
+			failed[i] = err
+			errs = append(errs, fmt.Errorf("repository %s: %w", repo.ID, err))
+			continue
+		}
+		accepted = append(accepted, repo.TelegramChatID)
+	}
+
+	return accepted, errors.Join(errs...)
+}
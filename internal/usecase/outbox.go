@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/pkg/metrics"
+)
+
+var outboxEvents = metrics.NewCounterVec(
+	"tgbot_outbox_events_total",
+	"Outbox events by stage: accepted, recovered, delivered, retried",
+	"result",
+)
+
+// outboxBatch - сколько записей забирается из outbox за один запрос
+const outboxBatch = 100
+
+// OutboxStore - постоянное хранилище принятых событий
+type OutboxStore interface {
+	// Add сохраняет событие и возвращает ID записи
+	Add(ctx context.Context, event *domain.Event) (int64, error)
+	// Claim отмечает до limit готовых к отправке записей как обрабатываемые и возвращает их
+	Claim(ctx context.Context, limit int) ([]domain.OutboxEntry, error)
+	// Complete отмечает запись доставленной
+	Complete(ctx context.Context, id int64) error
+	// Retry возвращает запись в ожидание до момента next с описанием ошибки;
+	// delivered - чаты, уведомления в которые уже приняты и при повторе не отправляются
+	Retry(ctx context.Context, id int64, delivered []string, cause error, next time.Time) error
+	// Recover возвращает в ожидание записи, обработка которых прервалась остановкой процесса
+	Recover(ctx context.Context) (int, error)
+}
+
+// OutboxDispatcher доставляет события из outbox: обработчик вебхука подтверждает хук только
+// после сохранения события, а запись закрывается, когда очередь доставки обработала все уведомления.
+// Доставка "хотя бы один раз": при остановке посреди отправки часть сообщений может прийти повторно.
+type OutboxDispatcher struct {
+	store    OutboxStore
+	notifier *Notifier
+	wake     chan struct{}
+	// retryInterval - базовая пауза перед повтором, если уведомления не удалось поставить в очередь
+	retryInterval time.Duration
+	now           func() time.Time
+}
+
+func NewOutboxDispatcher(store OutboxStore, notifier *Notifier, retryInterval time.Duration) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		store:         store,
+		notifier:      notifier,
+		wake:          make(chan struct{}, 1),
+		retryInterval: retryInterval,
+		now:           time.Now,
+	}
+}
+
+// Enqueue сохраняет событие в outbox; после успешного возврата хук можно подтверждать
+func (d *OutboxDispatcher) Enqueue(ctx context.Context, event *domain.Event) error {
+	id, err := d.store.Add(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to store event in outbox: %w", err)
+	}
+	outboxEvents.Inc("accepted")
+	log.Printf("📥 Событие %s проекта %s сохранено в outbox (#%d)", event.Kind, event.Key(), id)
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run восстанавливает незавершённые записи и доставляет события до отмены контекста
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	recovered, err := d.store.Recover(ctx)
+	if err != nil {
+		log.Printf("❌ Не удалось восстановить outbox: %v", err)
+	}
+	if recovered > 0 {
+		outboxEvents.Add("recovered", int64(recovered))
+		log.Printf("♻️ Восстановлено недоставленных событий из outbox: %d", recovered)
+	}
+
+	// Тикер подбирает записи, отложенные до повтора
+	ticker := time.NewTicker(d.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *OutboxDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		entries, err := d.store.Claim(ctx, outboxBatch)
+		if err != nil {
+			log.Printf("❌ Не удалось прочитать outbox: %v", err)
+			return
+		}
+
+		for _, entry := range entries {
+			d.deliver(ctx, entry)
+		}
+
+		if len(entries) < outboxBatch {
+			return
+		}
+	}
+}
+
+func (d *OutboxDispatcher) deliver(ctx context.Context, entry domain.OutboxEntry) {
+	// Запись закрывается из воркеров очереди, в том числе во время остановки
+	storeCtx := context.WithoutCancel(ctx)
+
+	tracker := &outboxDelivery{}
+	accepted, err := d.notifier.NotifyTracked(ctx, entry.ID, entry.Event, entry.DeliveredChats, tracker.done)
+	if err != nil && len(accepted) > 0 {
+		log.Printf("❌ Событие #%d доставлено не во все чаты, остальные будут повторены: %v", entry.ID, err)
+	}
+
+	tracker.expect(len(accepted), func(interrupted []string) {
+		if err == nil && len(interrupted) == 0 {
+			if err := d.store.Complete(storeCtx, entry.ID); err != nil {
+				log.Printf("❌ Не удалось отметить событие #%d доставленным: %v", entry.ID, err)
+				return
+			}
+			outboxEvents.Inc("delivered")
+			return
+		}
+
+		// Не все уведомления приняты (например, очередь переполнена) или их отправку прервала
+		// остановка - событие повторяется только для оставшихся чатов. Запись возвращается
+		// в ожидание, когда принятые уведомления обработаны: если процесс остановится раньше,
+		// они будут отправлены повторно, а не потеряны.
+		cause := err
+		if len(interrupted) > 0 {
+			cause = errors.Join(err, fmt.Errorf("delivery to %s interrupted by shutdown", strings.Join(interrupted, ", ")))
+		}
+		delivered := slices.DeleteFunc(append(slices.Clip(entry.DeliveredChats), accepted...), func(chatID string) bool {
+			return slices.Contains(interrupted, chatID)
+		})
+
+		next := d.now().Add(d.retryInterval * time.Duration(min(entry.Attempts+1, 10)))
+		if err := d.store.Retry(storeCtx, entry.ID, delivered, cause, next); err != nil {
+			log.Printf("❌ Не удалось отложить событие #%d: %v", entry.ID, err)
+			return
+		}
+		outboxEvents.Inc("retried")
+		log.Printf("⏳ Событие #%d будет доставлено повторно после %s: %v", entry.ID, next.Format(time.TimeOnly), cause)
+	})
+}
+
+// outboxDelivery ждёт, пока очередь обработает все уведомления события, и вызывает complete
+// с чатами, отправку в которые прервала остановка (очередь не подтверждает такие уведомления,
+// и без хранилища очереди на диске они потерялись бы). Уведомления могут быть обработаны
+// раньше, чем станет известно их количество.
+type outboxDelivery struct {
+	mu          sync.Mutex
+	delivered   int
+	expected    int
+	sealed      bool
+	interrupted []string
+	complete    func(interrupted []string)
+}
+
+func (t *outboxDelivery) done(chatID string, err error) {
+	t.mu.Lock()
+	t.delivered++
+	if errors.Is(err, context.Canceled) {
+		t.interrupted = append(t.interrupted, chatID)
+	}
+	finished := t.sealed && t.delivered == t.expected
+	complete, interrupted := t.complete, t.interrupted
+	t.mu.Unlock()
+
+	if finished {
+		complete(interrupted)
+	}
+}
+
+func (t *outboxDelivery) expect(n int, complete func(interrupted []string)) {
+	t.mu.Lock()
+	t.expected = n
+	t.complete = complete
+	t.sealed = true
+	finished := t.delivered == t.expected
+	interrupted := t.interrupted
+	t.mu.Unlock()
+
+	if finished {
+		complete(interrupted)
+	}
+}
@@ -0,0 +1,242 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/sqlite"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+)
+
+// memoryOutbox отдаёт записи один раз и запоминает, чем закончилась их обработка
+type memoryOutbox struct {
+	mu        sync.Mutex
+	entries   []domain.OutboxEntry
+	completed []int64
+	retried   map[int64][]string
+}
+
+func (m *memoryOutbox) Add(context.Context, *domain.Event) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (m *memoryOutbox) Claim(context.Context, int) ([]domain.OutboxEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := m.entries
+	m.entries = nil
+	return entries, nil
+}
+
+func (m *memoryOutbox) Complete(_ context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.completed = append(m.completed, id)
+	return nil
+}
+
+func (m *memoryOutbox) Retry(_ context.Context, id int64, delivered []string, _ error, _ time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retried[id] = delivered
+	return nil
+}
+
+func (m *memoryOutbox) Recover(context.Context) (int, error) { return 0, nil }
+
+func (m *memoryOutbox) finished() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.completed)+len(m.retried) > 0
+}
+
+func newTestNotifier(t *testing.T, history *usecase.EventHistory, sender usecase.MessageSender, chats ...string) *usecase.Notifier {
+	t.Helper()
+
+	var repos []domain.Repository
+	for _, chatID := range chats {
+		repos = append(repos, domain.Repository{ID: "1", TelegramChatID: chatID, Branches: []string{"main"}, Enabled: true})
+	}
+	routing, err := usecase.NewRoutingTable(repos, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priority, err := usecase.NewPriorityPolicy(nil, domain.PriorityNormal, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return usecase.NewNotifier(routing, priority, usecase.NewUnknownProjects(), history, nil, sender)
+}
+
+func TestOutboxRetriesSendsInterruptedByShutdown(t *testing.T) {
+	tests := []struct {
+		name          string
+		results       map[string]error
+		wantCompleted bool
+		wantDelivered []string
+	}{
+		{
+			name:          "all delivered",
+			results:       map[string]error{"-1": nil, "-2": nil},
+			wantCompleted: true,
+		},
+		{
+			name:          "send failed after retries",
+			results:       map[string]error{"-1": nil, "-2": errors.New("chat not found")},
+			wantCompleted: true,
+		},
+		{
+			name:          "send interrupted by shutdown",
+			results:       map[string]error{"-1": nil, "-2": &domain.DeliveryError{ChatID: "-2", Err: context.Canceled}},
+			wantDelivered: []string{"-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Отправитель сразу сообщает результат, как это делает очередь доставки после попытки
+			sender := senderFunc(func(_ context.Context, n domain.Notification) error {
+				n.Done(tt.results[n.ChatID])
+				return nil
+			})
+
+			store := &memoryOutbox{
+				entries: []domain.OutboxEntry{{ID: 7, Event: &domain.Event{Kind: domain.EventKindPipeline, ProjectID: "1", Ref: "main", Status: "failed"}}},
+				retried: make(map[int64][]string),
+			}
+			dispatcher := usecase.NewOutboxDispatcher(store, newTestNotifier(t, nil, sender, "-1", "-2"), time.Minute)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				dispatcher.Run(ctx)
+				close(done)
+			}()
+			waitFor(t, "outbox entry to be finished", store.finished)
+			cancel()
+			<-done
+
+			if got := slices.Contains(store.completed, 7); got != tt.wantCompleted {
+				t.Errorf("completed = %v, want %v", got, tt.wantCompleted)
+			}
+			if delivered, retried := store.retried[7]; retried == tt.wantCompleted || !slices.Equal(delivered, tt.wantDelivered) {
+				t.Errorf("retried = %v with delivered %q, want retry with %q", retried, delivered, tt.wantDelivered)
+			}
+		})
+	}
+}
+
+// runOutboxOnce обрабатывает записи outbox и останавливает диспетчер, когда запись закрыта или отложена
+func runOutboxOnce(t *testing.T, dispatcher *usecase.OutboxDispatcher, store *memoryOutbox) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+	waitFor(t, "outbox entry to be finished", store.finished)
+	cancel()
+	<-done
+}
+
+func TestOutboxRetriesUpdateOneHistoryRecord(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		mu       sync.Mutex
+		attempts = make(map[string]int)
+	)
+	// Первая отправка в чат -2 прерывается остановкой, вторая проходит
+	sender := senderFunc(func(_ context.Context, n domain.Notification) error {
+		mu.Lock()
+		attempts[n.ChatID]++
+		var err error
+		if n.ChatID == "-2" && attempts[n.ChatID] == 1 {
+			err = context.Canceled
+		}
+		mu.Unlock()
+
+		n.Done(err)
+		return nil
+	})
+
+	history := usecase.NewEventHistory(sqlite.NewEvents(db))
+	notifier := newTestNotifier(t, history, sender, "-1", "-2")
+
+	occurredAt := time.Date(2026, 9, 30, 23, 59, 0, 0, time.UTC)
+	entry := domain.OutboxEntry{
+		ID:    7,
+		Event: &domain.Event{Kind: domain.EventKindPipeline, ProjectID: "1", Ref: "main", Status: "failed", Timestamp: occurredAt},
+	}
+	store := &memoryOutbox{entries: []domain.OutboxEntry{entry}, retried: make(map[int64][]string)}
+	dispatcher := usecase.NewOutboxDispatcher(store, notifier, time.Minute)
+
+	runOutboxOnce(t, dispatcher, store)
+	delivered, ok := store.retried[7]
+	if !ok {
+		t.Fatal("first attempt must be retried")
+	}
+
+	entry.Attempts = 1
+	entry.DeliveredChats = delivered
+	store.entries, store.retried = []domain.OutboxEntry{entry}, make(map[int64][]string)
+	runOutboxOnce(t, dispatcher, store)
+	if !slices.Contains(store.completed, 7) {
+		t.Fatal("second attempt must complete the entry")
+	}
+
+	records, err := history.Query(ctx, domain.EventFilter{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("history records = %d, want one record for all attempts", len(records))
+	}
+	record := records[0]
+	if record.Outcome != domain.OutcomeDelivered || record.OutboxID != 7 {
+		t.Errorf("outcome = %s, outbox_id = %d, want delivered from outbox entry 7", record.Outcome, record.OutboxID)
+	}
+	for _, d := range record.Deliveries {
+		if !d.Matched || !d.Done || d.Error != "" {
+			t.Errorf("delivery to %s = %+v, want matched and delivered", d.ChatID, d)
+		}
+	}
+	if attempts["-1"] != 1 || attempts["-2"] != 2 {
+		t.Errorf("sends = %v, want one to -1 and two to -2", attempts)
+	}
+
+	// Фильтр по времени использует время события в GitLab, а не время обработки
+	if !record.OccurredAt.Equal(occurredAt) {
+		t.Errorf("occurred_at = %s, want %s", record.OccurredAt, occurredAt)
+	}
+	for _, tt := range []struct {
+		filter domain.EventFilter
+		want   int
+	}{
+		{domain.EventFilter{Since: occurredAt, Limit: 10}, 1},
+		{domain.EventFilter{Until: occurredAt.Add(time.Minute), Limit: 10}, 1},
+		{domain.EventFilter{Since: occurredAt.Add(time.Minute), Limit: 10}, 0},
+	} {
+		records, err := history.Query(ctx, tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != tt.want {
+			t.Errorf("Query(since %s, until %s) = %d records, want %d", tt.filter.Since, tt.filter.Until, len(records), tt.want)
+		}
+	}
+}
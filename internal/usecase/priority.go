@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"fmt"
+	"html"
+	"log"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/pkg/expr"
+)
+
+// PriorityRule - правило: если условие истинно, событие получает указанный приоритет
+type PriorityRule struct {
+	Condition string
+	Priority  domain.Priority
+}
+
+type compiledPriorityRule struct {
+	program  *expr.Program
+	priority domain.Priority
+}
+
+// PriorityPolicy определяет приоритет уведомления и связанное с ним поведение доставки
+type PriorityPolicy struct {
+	rules           []compiledPriorityRule
+	defaultPriority domain.Priority
+	pinCritical     bool
+	mention         string
+}
+
+// NewPriorityPolicy компилирует правила. Правила проверяются по порядку, срабатывает первое подходящее.
+// Для критичных уведомлений pinCritical включает закрепление, а mention (например, "@oncall")
+// добавляется в текст сообщения.
+func NewPriorityPolicy(rules []PriorityRule, defaultPriority domain.Priority, pinCritical bool, mention string) (*PriorityPolicy, error) {
+	policy := &PriorityPolicy{
+		defaultPriority: defaultPriority,
+		pinCritical:     pinCritical,
+		mention:         mention,
+	}
+
+	for i, rule := range rules {
+		program, err := domain.CompileCondition(rule.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("priority rule %d: invalid condition: %w", i, err)
+		}
+		policy.rules = append(policy.rules, compiledPriorityRule{program: program, priority: rule.Priority})
+	}
+
+	return policy, nil
+}
+
+// Resolve возвращает приоритет события
+func (p *PriorityPolicy) Resolve(event *domain.Event) domain.Priority {
+	if len(p.rules) == 0 {
+		return p.defaultPriority
+	}
+
+	env := event.Env()
+	for i, rule := range p.rules {
+		ok, err := rule.program.Eval(env)
+		if err != nil {
+			// Ошибка вычисления (например, несовпадение типов) не должна блокировать доставку
+			log.Printf("⚠️ Правило приоритета %d пропущено для события %s проекта %s: condition error: %v",
+				i, event.Kind, event.Key(), err)
+			continue
+		}
+		if ok {
+			return rule.priority
+		}
+	}
+
+	return p.defaultPriority
+}
+
+// Apply выставляет уведомлению приоритет и параметры доставки:
+// низкий приоритет - без звука, критичный - закрепление и упоминание дежурного
+func (p *PriorityPolicy) Apply(n *domain.Notification, priority domain.Priority) {
+	n.Priority = priority
+
+	switch priority {
+	case domain.PriorityLow:
+		n.DisableNotification = true
+	case domain.PriorityCritical:
+		n.Pin = p.pinCritical
+		if p.mention != "" {
+			n.Message += "\n\n🚨 " + html.EscapeString(p.mention)
+		}
+	}
+}
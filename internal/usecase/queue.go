@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// ErrQueueFull - очередь доставки переполнена
+var ErrQueueFull = errors.New("delivery queue is full")
+
+// QueueStore - хранилище уведомлений очереди доставки. Порядок доставки определяет очередь,
+// хранилище отвечает только за то, чтобы принятые уведомления пережили перезапуск.
+type QueueStore interface {
+	// Append сохраняет уведомление и возвращает его номер; номера возрастают
+	Append(n domain.Notification) (uint64, error)
+	// Ack отмечает уведомление обработанным
+	Ack(id uint64) error
+	// Pending возвращает сохранённые, но не обработанные уведомления в порядке добавления
+	Pending() ([]domain.QueuedNotification, error)
+}
+
+// MemoryQueueStore - очередь только в памяти: уведомления, не доставленные до остановки, теряются
+type MemoryQueueStore struct {
+	seq atomic.Uint64
+}
+
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{}
+}
+
+func (s *MemoryQueueStore) Append(domain.Notification) (uint64, error) {
+	return s.seq.Add(1), nil
+}
+
+func (s *MemoryQueueStore) Ack(uint64) error { return nil }
+
+func (s *MemoryQueueStore) Pending() ([]domain.QueuedNotification, error) { return nil, nil }
+
+// DeliveryQueue - очередь доставки уведомлений с приоритетами.
+// Сначала доставляются уведомления с более высоким приоритетом,
+// при равном приоритете - в порядке поступления. В каждый чат одновременно
+// отправляется не больше одного уведомления, поэтому порядок сохраняется и внутри чата,
+// а воркеры параллельно обслуживают разные чаты.
+type DeliveryQueue struct {
+	mu    sync.Mutex
+	items queueHeap
+	// busy - чаты, в которые сейчас идёт отправка
+	busy     map[string]bool
+	store    QueueStore
+	capacity int
+	ready    chan struct{}
+	sender   MessageSender
+}
+
+func NewDeliveryQueue(sender MessageSender, store QueueStore, capacity int) *DeliveryQueue {
+	return &DeliveryQueue{
+		busy:     make(map[string]bool),
+		store:    store,
+		capacity: capacity,
+		ready:    make(chan struct{}, 1),
+		sender:   sender,
+	}
+}
+
+// SendMessage ставит уведомление в очередь (реализует MessageSender)
+func (q *DeliveryQueue) SendMessage(_ context.Context, n domain.Notification) error {
+	q.mu.Lock()
+	if q.capacity > 0 && q.items.Len() >= q.capacity {
+		q.mu.Unlock()
+		return ErrQueueFull
+	}
+
+	id, err := q.store.Append(n)
+	if err != nil {
+		q.mu.Unlock()
+		return fmt.Errorf("failed to store notification in delivery queue: %w", err)
+	}
+	heap.Push(&q.items, queueItem{notification: n, seq: id})
+	q.mu.Unlock()
+
+	// Будим воркер, не блокируясь, если сигнал уже отправлен
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Len возвращает количество уведомлений в очереди
+func (q *DeliveryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
+
+// Run восстанавливает уведомления, не доставленные до остановки, запускает воркеры доставки
+// и блокируется до отмены контекста
+func (q *DeliveryQueue) Run(ctx context.Context, workers int) {
+	if err := q.restore(); err != nil {
+		log.Printf("❌ Не удалось восстановить очередь доставки: %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	if pending := q.Len(); pending > 0 {
+		log.Printf("⚠️ Очередь доставки остановлена, не доставлено уведомлений: %d", pending)
+	}
+}
+
+func (q *DeliveryQueue) work(ctx context.Context) {
+	for {
+		item, ok := q.pop()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.ready:
+				continue
+			}
+		}
+
+		n := item.notification
+		err := q.sender.SendMessage(ctx, n)
+		if err != nil && !errors.Is(err, domain.ErrHeld) {
+			log.Printf("❌ Не удалось доставить уведомление (%s) в чат %s: %v", n.Priority, n.ChatID, err)
+		}
+		// Отправка, прерванная остановкой, не подтверждается: уведомление восстановится после перезапуска
+		if err == nil || ctx.Err() == nil {
+			if err := q.store.Ack(item.seq); err != nil {
+				log.Printf("❌ Не удалось отметить уведомление #%d обработанным: %v", item.seq, err)
+			}
+		}
+		if n.Done != nil {
+			n.Done(err)
+		}
+		q.release(n.ChatID)
+
+		// Сигнал мог быть поглощён другим воркером - передаём его дальше, пока очередь не пуста
+		if q.Len() > 0 {
+			select {
+			case q.ready <- struct{}{}:
+			default:
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// pop извлекает уведомление с наибольшим приоритетом среди чатов, в которые сейчас
+// ничего не отправляется, и отмечает его чат занятым до release
+func (q *DeliveryQueue) pop() (queueItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var skipped []queueItem
+	defer func() {
+		for _, item := range skipped {
+			heap.Push(&q.items, item)
+		}
+	}()
+
+	for q.items.Len() > 0 {
+		item := heap.Pop(&q.items).(queueItem)
+		if q.busy[item.notification.ChatID] {
+			skipped = append(skipped, item)
+			continue
+		}
+
+		q.busy[item.notification.ChatID] = true
+		return item, true
+	}
+
+	return queueItem{}, false
+}
+
+func (q *DeliveryQueue) release(chatID string) {
+	q.mu.Lock()
+	delete(q.busy, chatID)
+	q.mu.Unlock()
+}
+
+// restore возвращает в очередь уведомления, сохранённые хранилищем до остановки
+func (q *DeliveryQueue) restore() error {
+	pending, err := q.store.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	q.mu.Lock()
+	for _, p := range pending {
+		heap.Push(&q.items, queueItem{notification: p.Notification, seq: p.ID})
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+
+	log.Printf("♻️ Восстановлено уведомлений в очереди доставки: %d", len(pending))
+	return nil
+}
+
+type queueItem struct {
+	notification domain.Notification
+	// seq - номер уведомления в хранилище очереди, он же порядок поступления
+	seq uint64
+}
+
+// queueHeap реализует heap.Interface: максимальный приоритет, затем минимальный seq
+type queueHeap []queueItem
+
+func (h queueHeap) Len() int { return len(h) }
+
+func (h queueHeap) Less(i, j int) bool {
+	if h[i].notification.Priority != h[j].notification.Priority {
+		return h[i].notification.Priority > h[j].notification.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h queueHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *queueHeap) Push(x any) { *h = append(*h, x.(queueItem)) }
+
+func (h *queueHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,241 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/internal/usecase"
+)
+
+// recordingQueueStore - QueueStore в памяти, запоминающий подтверждённые номера
+type recordingQueueStore struct {
+	mu     sync.Mutex
+	seq    uint64
+	acked  []uint64
+	queued []domain.QueuedNotification
+}
+
+func (s *recordingQueueStore) Append(n domain.Notification) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	s.queued = append(s.queued, domain.QueuedNotification{ID: s.seq, Notification: n})
+	return s.seq, nil
+}
+
+func (s *recordingQueueStore) Ack(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acked = append(s.acked, id)
+	return nil
+}
+
+func (s *recordingQueueStore) Pending() ([]domain.QueuedNotification, error) {
+	return nil, nil
+}
+
+func (s *recordingQueueStore) ackedIDs() []uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.acked)
+}
+
+// senderFunc позволяет задать отправителя функцией
+type senderFunc func(ctx context.Context, n domain.Notification) error
+
+func (f senderFunc) SendMessage(ctx context.Context, n domain.Notification) error {
+	return f(ctx, n)
+}
+
+// runQueue запускает воркеры очереди и возвращает функцию, которая останавливает их и ждёт завершения
+func runQueue(q *usecase.DeliveryQueue, workers int) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx, workers)
+		close(done)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDeliveryQueuePriorityOrder(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	sender := senderFunc(func(_ context.Context, n domain.Notification) error {
+		mu.Lock()
+		sent = append(sent, n.Message)
+		mu.Unlock()
+		return nil
+	})
+
+	q := usecase.NewDeliveryQueue(sender, &recordingQueueStore{}, 0)
+	queued := []domain.Notification{
+		{ChatID: "-1", Message: "low", Priority: domain.PriorityLow},
+		{ChatID: "-2", Message: "normal 1", Priority: domain.PriorityNormal},
+		{ChatID: "-3", Message: "critical", Priority: domain.PriorityCritical},
+		{ChatID: "-1", Message: "normal 2", Priority: domain.PriorityNormal},
+		{ChatID: "-2", Message: "high", Priority: domain.PriorityHigh},
+	}
+	for _, n := range queued {
+		if err := q.SendMessage(context.Background(), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Один воркер: порядок отправки совпадает с порядком извлечения из очереди
+	stop := runQueue(q, 1)
+	waitFor(t, "all notifications", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sent) == len(queued)
+	})
+	stop()
+
+	want := []string{"critical", "high", "normal 1", "normal 2", "low"}
+	if !slices.Equal(sent, want) {
+		t.Errorf("delivery order = %q, want %q", sent, want)
+	}
+}
+
+func TestDeliveryQueueCapacity(t *testing.T) {
+	q := usecase.NewDeliveryQueue(senderFunc(func(context.Context, domain.Notification) error { return nil }), &recordingQueueStore{}, 1)
+
+	if err := q.SendMessage(context.Background(), domain.Notification{ChatID: "-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SendMessage(context.Background(), domain.Notification{ChatID: "-1"}); !errors.Is(err, usecase.ErrQueueFull) {
+		t.Errorf("SendMessage to a full queue = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestDeliveryQueueOneMessagePerChat(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight = make(map[string]int)
+		maxSeen  = make(map[string]int)
+		sent     []string
+	)
+	release := make(chan struct{})
+	sender := senderFunc(func(_ context.Context, n domain.Notification) error {
+		mu.Lock()
+		inFlight[n.ChatID]++
+		maxSeen[n.ChatID] = max(maxSeen[n.ChatID], inFlight[n.ChatID])
+		mu.Unlock()
+
+		// Сообщения чата -1 ждут, пока не будет отправлено сообщение чата -2
+		if n.ChatID == "-1" {
+			<-release
+		}
+
+		mu.Lock()
+		inFlight[n.ChatID]--
+		sent = append(sent, n.Message)
+		mu.Unlock()
+		return nil
+	})
+
+	q := usecase.NewDeliveryQueue(sender, &recordingQueueStore{}, 0)
+	for _, n := range []domain.Notification{
+		{ChatID: "-1", Message: "a1"},
+		{ChatID: "-1", Message: "a2"},
+		{ChatID: "-1", Message: "a3"},
+		{ChatID: "-2", Message: "b1"},
+	} {
+		if err := q.SendMessage(context.Background(), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stop := runQueue(q, 4)
+	// Занятый чат -1 не блокирует воркеры для других чатов
+	waitFor(t, "message to the second chat", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Contains(sent, "b1")
+	})
+	close(release)
+	waitFor(t, "all notifications", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sent) == 4
+	})
+	stop()
+
+	if maxSeen["-1"] != 1 {
+		t.Errorf("max concurrent sends to one chat = %d, want 1", maxSeen["-1"])
+	}
+	var chat []string
+	for _, m := range sent {
+		if m != "b1" {
+			chat = append(chat, m)
+		}
+	}
+	if want := []string{"a1", "a2", "a3"}; !slices.Equal(chat, want) {
+		t.Errorf("order within chat = %q, want %q", chat, want)
+	}
+}
+
+func TestDeliveryQueueDoesNotAckInterruptedSend(t *testing.T) {
+	started := make(chan struct{})
+	sender := senderFunc(func(ctx context.Context, n domain.Notification) error {
+		if n.Message == "slow" {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	store := &recordingQueueStore{}
+	q := usecase.NewDeliveryQueue(sender, store, 0)
+
+	var doneErr error
+	doneCalled := make(chan struct{})
+	if err := q.SendMessage(context.Background(), domain.Notification{ChatID: "-1", Message: "fast"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SendMessage(context.Background(), domain.Notification{
+		ChatID:  "-1",
+		Message: "slow",
+		Done: func(err error) {
+			doneErr = err
+			close(doneCalled)
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := runQueue(q, 1)
+	<-started
+	stop()
+	<-doneCalled
+
+	if acked := store.ackedIDs(); !slices.Equal(acked, []uint64{1}) {
+		t.Errorf("acked = %v, want only the delivered notification 1", acked)
+	}
+	if !errors.Is(doneErr, context.Canceled) {
+		t.Errorf("Done error = %v, want context.Canceled", doneErr)
+	}
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// HeldMessageStore - хранилище сообщений, отложенных на тихие часы. Сообщение удаляется
+// из хранилища (Ack) только после отправки, поэтому падение во время доставки его не теряет.
+type HeldMessageStore interface {
+	Hold(msg domain.HeldMessage) error
+	Chats() []string
+	// Peek возвращает отложенные сообщения чата в порядке откладывания, не удаляя их
+	Peek(chatID string) []domain.HeldMessage
+	// Ack удаляет доставленные сообщения чата по ID
+	Ack(chatID string, ids []int64) error
+}
+
+// maxMessageLength - ограничение Telegram на длину текста сообщения
+const maxMessageLength = 4096
+
+// QuietHoursSender применяет расписание тихих часов чата перед отправкой:
+// сообщение уходит без звука или откладывается до конца тихих часов
+type QuietHoursSender struct {
+	next MessageSender
+	// release отправляет отложенные пачки; с очередью недоставленных - через DeadLetterSender
+	release     MessageSender
+	deadLetters bool
+	store       HeldMessageStore
+	schedules   map[string]domain.QuietSchedule
+	// chats - язык заголовка пачки; nil - язык по умолчанию
+	chats *ChatSettings
+	now   func() time.Time
+}
+
+// NewQuietHoursSender создаёт отправителя с тихими часами. deadLetters - хранилище недоставленных
+// (nil - очередь недоставленных отключена): отложенные пачки, которые не удалось отправить,
+// сохраняются в него так же, как обычные уведомления.
+func NewQuietHoursSender(next MessageSender, store HeldMessageStore, schedules map[string]domain.QuietSchedule, chats *ChatSettings, deadLetters DeadLetterStore) *QuietHoursSender {
+	s := &QuietHoursSender{
+		next:      next,
+		release:   next,
+		store:     store,
+		schedules: schedules,
+		chats:     chats,
+		now:       time.Now,
+	}
+	if deadLetters != nil {
+		s.release = NewDeadLetterSender(next, deadLetters)
+		s.deadLetters = true
+	}
+
+	return s
+}
+
+// SendMessage реализует MessageSender
+func (s *QuietHoursSender) SendMessage(ctx context.Context, n domain.Notification) error {
+	schedule, ok := s.schedules[n.ChatID]
+	if !ok || !schedule.IsQuiet(s.now()) {
+		return s.next.SendMessage(ctx, n)
+	}
+
+	if schedule.Mode == domain.QuietModeHold {
+		if err := s.store.Hold(domain.HeldMessage{Notification: n, HeldAt: s.now()}); err != nil {
+			return fmt.Errorf("failed to hold message: %w", err)
+		}
+		log.Printf("🌙 Сообщение для чата %s отложено до окончания тихих часов", n.ChatID)
+		return domain.ErrHeld
+	}
+
+	n.DisableNotification = true
+	return s.next.SendMessage(ctx, n)
+}
+
+// Run периодически отправляет отложенные сообщения тем чатам, у которых закончились тихие часы
+func (s *QuietHoursSender) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Сразу после старта досылаем то, что было отложено до перезапуска
+	s.releaseHeld(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.releaseHeld(ctx)
+		}
+	}
+}
+
+func (s *QuietHoursSender) releaseHeld(ctx context.Context) {
+	now := s.now()
+
+	for _, chatID := range s.store.Chats() {
+		if schedule, ok := s.schedules[chatID]; ok && schedule.IsQuiet(now) {
+			continue
+		}
+
+		held := s.store.Peek(chatID)
+		if len(held) == 0 {
+			continue
+		}
+
+		if err := s.deliverHeld(ctx, chatID, held); err != nil {
+			log.Printf("❌ Не удалось доставить отложенные сообщения чата %s, повтор при следующей проверке: %v", chatID, err)
+			continue
+		}
+
+		log.Printf("☀️ Отложенные сообщения чата %s отправлены: %d", chatID, len(held))
+	}
+}
+
+// deliverHeld отправляет отложенные сообщения пачками. Сообщения одной темы форума и одной
+// ветки объединяются в пачку, чтобы она попала в ту же тему и ответом в ту же ветку, что и
+// исходные уведомления. Сообщения, которые не удалось отправить, остаются в хранилище.
+func (s *QuietHoursSender) deliverHeld(ctx context.Context, chatID string, held []domain.HeldMessage) error {
+	texts := messageLanguages[s.chats.Get(chatID).LanguageOrDefault()]
+
+	var errs []error
+	for _, batch := range groupHeld(held) {
+		if err := s.deliverBatch(ctx, chatID, held, batch, texts); err != nil {
+			errs = append(errs, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// heldBatchKey - тема форума и ветка сообщения
+type heldBatchKey struct {
+	topic  int
+	thread string
+}
+
+// groupHeld разбивает отложенные сообщения на пачки (индексы в held) в порядке первого сообщения пачки
+func groupHeld(held []domain.HeldMessage) [][]int {
+	var batches [][]int
+	index := make(map[heldBatchKey]int)
+
+	for i, msg := range held {
+		key := heldBatchKey{topic: msg.Notification.MessageThreadID, thread: msg.Notification.Thread}
+		j, ok := index[key]
+		if !ok {
+			j = len(batches)
+			index[key] = j
+			batches = append(batches, nil)
+		}
+		batches[j] = append(batches[j], i)
+	}
+
+	return batches
+}
+
+// heldChunk - часть пачки, умещающаяся в одно сообщение Telegram
+type heldChunk struct {
+	text    string
+	members []int
+}
+
+// deliverBatch отправляет пачку отложенных сообщений, разбивая её на части, если превышен
+// лимит длины сообщения. Сообщения каждой отправленной части сразу удаляются из хранилища.
+func (s *QuietHoursSender) deliverBatch(ctx context.Context, chatID string, held []domain.HeldMessage, batch []int, texts messageTexts) error {
+	header := fmt.Sprintf(texts.held, len(batch))
+	const separator = "\n\n〰️〰️〰️\n\n"
+
+	chunks := []heldChunk{{text: header}}
+
+	for _, i := range batch {
+		text := held[i].Notification.Message
+		last := &chunks[len(chunks)-1]
+
+		if len([]rune(last.text))+len([]rune(separator))+len([]rune(text)) > maxMessageLength {
+			chunks = append(chunks, heldChunk{text: text, members: []int{i}})
+			continue
+		}
+		last.text = strings.Join([]string{last.text, text}, separator)
+		last.members = append(last.members, i)
+	}
+
+	for _, chunk := range chunks {
+		// Первая часть может состоять из одного заголовка, если первое сообщение слишком длинное
+		if len(chunk.members) == 0 {
+			continue
+		}
+
+		sendErr := s.release.SendMessage(ctx, heldNotification(held, chunk))
+		if sendErr != nil && !s.undeliverable(ctx, sendErr) {
+			return sendErr
+		}
+
+		ids := make([]int64, 0, len(chunk.members))
+		for _, i := range chunk.members {
+			ids = append(ids, held[i].ID)
+		}
+		if err := s.store.Ack(chatID, ids); err != nil {
+			return fmt.Errorf("failed to remove delivered held messages: %w", err)
+		}
+
+		if sendErr != nil && !s.deadLetters {
+			log.Printf("❌ Отложенные сообщения чата %s (%d) не доставлены и удалены: %v", chatID, len(ids), sendErr)
+		}
+	}
+
+	return nil
+}
+
+// undeliverable сообщает, что пачку больше не нужно держать в отложенных: она сохранена
+// в очередь недоставленных или повтор не поможет (бота удалили из чата). Прерванная
+// остановкой и временно неудачная без очереди недоставленных отправка повторяется позже.
+func (s *QuietHoursSender) undeliverable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if s.deadLetters {
+		return true
+	}
+
+	var deliveryErr *domain.DeliveryError
+	return errors.As(err, &deliveryErr) && deliveryErr.Permanent
+}
+
+// heldNotification собирает уведомление из части пачки. Тема, ветка и формат берутся
+// у сообщений пачки (они совпадают); приоритет - наибольший, без звука - только если
+// все сообщения были без звука, закрепляется - если закреплялось любое из них.
+func heldNotification(held []domain.HeldMessage, chunk heldChunk) domain.Notification {
+	first := held[chunk.members[0]].Notification
+
+	n := domain.Notification{
+		ChatID:              first.ChatID,
+		Message:             chunk.text,
+		ParseMode:           first.ParseMode,
+		Priority:            first.Priority,
+		DisableNotification: true,
+		MessageThreadID:     first.MessageThreadID,
+		Thread:              first.Thread,
+	}
+	for _, i := range chunk.members {
+		msg := held[i].Notification
+		n.Priority = max(n.Priority, msg.Priority)
+		n.DisableNotification = n.DisableNotification && msg.DisableNotification
+		n.Pin = n.Pin || msg.Pin
+	}
+
+	return n
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/adapter/filestore"
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// recordingSender записывает отправленные уведомления; errs - ошибки очередных отправок
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []domain.Notification
+	errs []error
+}
+
+func (s *recordingSender) SendMessage(_ context.Context, n domain.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+// memoryDeadLetters - DeadLetterStore в памяти
+type memoryDeadLetters struct {
+	mu      sync.Mutex
+	lastID  int64
+	letters []domain.DeadLetter
+}
+
+func (m *memoryDeadLetters) Add(_ context.Context, letter domain.DeadLetter) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastID++
+	letter.ID = m.lastID
+	m.letters = append(m.letters, letter)
+	return letter.ID, nil
+}
+
+func (m *memoryDeadLetters) List(_ context.Context, chatID string, limit int) ([]domain.DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var letters []domain.DeadLetter
+	for _, letter := range m.letters {
+		if chatID == "" || letter.Notification.ChatID == chatID {
+			letters = append(letters, letter)
+		}
+	}
+	return letters[:min(limit, len(letters))], nil
+}
+
+func (m *memoryDeadLetters) Get(_ context.Context, id int64) (domain.DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, letter := range m.letters {
+		if letter.ID == id {
+			return letter, nil
+		}
+	}
+	return domain.DeadLetter{}, domain.ErrDeadLetterNotFound
+}
+
+func (m *memoryDeadLetters) Delete(_ context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, letter := range m.letters {
+		if letter.ID == id {
+			m.letters = append(m.letters[:i], m.letters[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrDeadLetterNotFound
+}
+
+func (m *memoryDeadLetters) Purge(context.Context, string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.letters)
+	m.letters = nil
+	return n, nil
+}
+
+func (m *memoryDeadLetters) DeleteBefore(context.Context, time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *memoryDeadLetters) Count(context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.letters), nil
+}
+
+var alwaysQuiet = domain.QuietSchedule{
+	Location: time.UTC,
+	Mode:     domain.QuietModeHold,
+	WeekendDays: []time.Weekday{
+		time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday,
+	},
+}
+
+// holdMessages откладывает сообщения через SendMessage и снимает тихие часы
+func holdMessages(t *testing.T, s *QuietHoursSender, notifications ...domain.Notification) {
+	t.Helper()
+	for _, n := range notifications {
+		s.schedules[n.ChatID] = alwaysQuiet
+		if err := s.SendMessage(context.Background(), n); !errors.Is(err, domain.ErrHeld) {
+			t.Fatalf("SendMessage during quiet hours = %v, want ErrHeld", err)
+		}
+	}
+	clear(s.schedules)
+}
+
+func openHeldStore(t *testing.T, path string) *filestore.HeldMessageStore {
+	t.Helper()
+	store, err := filestore.NewHeldMessageStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestQuietHoursKeepsHeldMessagesUntilSent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "held.json")
+	next := &recordingSender{errs: []error{errors.New("connection reset")}}
+	s := NewQuietHoursSender(next, openHeldStore(t, path), map[string]domain.QuietSchedule{}, nil, nil)
+
+	holdMessages(t, s,
+		domain.Notification{ChatID: "-1", Message: "first"},
+		domain.Notification{ChatID: "-1", Message: "second", MessageThreadID: 7},
+	)
+
+	// Первая пачка не отправилась: её сообщение остаётся в хранилище и переживает перезапуск
+	s.releaseHeld(context.Background())
+	held := openHeldStore(t, path).Peek("-1")
+	if len(held) != 1 || held[0].Notification.Message != "first" {
+		t.Fatalf("held after failed release = %+v, want only the first message", held)
+	}
+
+	s.releaseHeld(context.Background())
+	if held := openHeldStore(t, path).Peek("-1"); len(held) != 0 {
+		t.Fatalf("held after release = %+v, want none", held)
+	}
+
+	if len(next.sent) != 2 {
+		t.Fatalf("sent %d batches, want 2", len(next.sent))
+	}
+	if next.sent[0].MessageThreadID != 7 || next.sent[1].MessageThreadID != 0 {
+		t.Errorf("batches went to topics %d and %d, want 7 and 0", next.sent[0].MessageThreadID, next.sent[1].MessageThreadID)
+	}
+	if !strings.HasPrefix(next.sent[1].Message, "🌙 <b>Уведомления за время тишины (1)</b>") {
+		t.Errorf("batch header = %q", next.sent[1].Message)
+	}
+}
+
+func TestQuietHoursDropsUndeliverableBatches(t *testing.T) {
+	permanent := &domain.DeliveryError{ChatID: "-1", Attempts: 1, Err: errors.New("chat not found"), Permanent: true}
+	temporary := &domain.DeliveryError{ChatID: "-1", Attempts: 3, Err: errors.New("bad gateway")}
+
+	tests := []struct {
+		name        string
+		err         error
+		deadLetters bool
+		wantHeld    int
+		wantLetters int
+	}{
+		{name: "permanent error", err: permanent, wantHeld: 0},
+		{name: "temporary error", err: temporary, wantHeld: 1},
+		{name: "temporary error with dead letters", err: temporary, deadLetters: true, wantHeld: 0, wantLetters: 1},
+		{name: "shutdown with dead letters", err: context.Canceled, deadLetters: true, wantHeld: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := openHeldStore(t, filepath.Join(t.TempDir(), "held.json"))
+			letters := &memoryDeadLetters{}
+			var deadLetters DeadLetterStore
+			if tt.deadLetters {
+				deadLetters = letters
+			}
+			s := NewQuietHoursSender(&recordingSender{errs: []error{tt.err}}, store, map[string]domain.QuietSchedule{}, nil, deadLetters)
+			holdMessages(t, s, domain.Notification{ChatID: "-1", Message: "text"})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if errors.Is(tt.err, context.Canceled) {
+				cancel()
+			}
+			defer cancel()
+			s.releaseHeld(ctx)
+
+			if held := store.Peek("-1"); len(held) != tt.wantHeld {
+				t.Errorf("held = %d, want %d", len(held), tt.wantHeld)
+			}
+			if len(letters.letters) != tt.wantLetters {
+				t.Errorf("dead letters = %d, want %d", len(letters.letters), tt.wantLetters)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// RepositoryRegistry - хранилище конфигурации репозиториев: repositories.json (только чтение)
+// или база данных с изменением через административное API
+type RepositoryRegistry interface {
+	List(ctx context.Context) (domain.RepositorySet, error)
+	Create(ctx context.Context, repo domain.Repository) error
+	Update(ctx context.Context, key domain.RouteKey, repo domain.Repository) error
+	Delete(ctx context.Context, key domain.RouteKey) error
+	SetDefaultRoute(ctx context.Context, route *domain.Repository) error
+}
+
+// ValidationError - изменение отклонено, потому что итоговая конфигурация некорректна
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string { return e.Err.Error() }
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// RepositoryService изменяет конфигурацию репозиториев и сразу применяет её к маршрутизации.
+// Каждое изменение проверяется на полной конфигурации (дубликаты, ссылки на инстансы GitLab)
+// до записи в хранилище.
+type RepositoryService struct {
+	// mu упорядочивает изменения: проверка и запись выполняются над одной и той же версией
+	mu       sync.Mutex
+	registry RepositoryRegistry
+	notifier *Notifier
+	validate func(domain.RepositorySet) error
+}
+
+func NewRepositoryService(registry RepositoryRegistry, notifier *Notifier, validate func(domain.RepositorySet) error) *RepositoryService {
+	return &RepositoryService{
+		registry: registry,
+		notifier: notifier,
+		validate: validate,
+	}
+}
+
+// Load читает конфигурацию из хранилища и применяет её
+func (s *RepositoryService) Load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set, err := s.registry.List(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(set); err != nil {
+		return &ValidationError{Err: err}
+	}
+	return s.apply(set)
+}
+
+// Apply применяет уже проверенную конфигурацию (например, перечитанный repositories.json)
+func (s *RepositoryService) Apply(set domain.RepositorySet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.apply(set)
+}
+
+func (s *RepositoryService) List(ctx context.Context) (domain.RepositorySet, error) {
+	return s.registry.List(ctx)
+}
+
+func (s *RepositoryService) Get(ctx context.Context, key domain.RouteKey) (domain.Repository, error) {
+	set, err := s.registry.List(ctx)
+	if err != nil {
+		return domain.Repository{}, err
+	}
+
+	i := set.Index(key)
+	if i < 0 {
+		return domain.Repository{}, domain.ErrRepositoryNotFound
+	}
+	return set.Repositories[i], nil
+}
+
+// Create добавляет запись репозитория
+func (s *RepositoryService) Create(ctx context.Context, repo domain.Repository) error {
+	return s.change(ctx, "создан", repo.RouteKey(), func(set *domain.RepositorySet) error {
+		if set.Index(repo.RouteKey()) >= 0 {
+			return domain.ErrRepositoryExists
+		}
+		set.Repositories = append(set.Repositories, repo)
+		return nil
+	}, func() error {
+		return s.registry.Create(ctx, repo)
+	})
+}
+
+// Update заменяет запись с ключом key; ключ самой записи может измениться (например, чат).
+// Секреты, переданные скрытыми (*** из ответа API), сохраняют текущие значения.
+func (s *RepositoryService) Update(ctx context.Context, key domain.RouteKey, repo domain.Repository) error {
+	return s.change(ctx, "изменён", key, func(set *domain.RepositorySet) error {
+		i := set.Index(key)
+		if i < 0 {
+			return domain.ErrRepositoryNotFound
+		}
+		if newKey := repo.RouteKey(); newKey != key && set.Index(newKey) >= 0 {
+			return domain.ErrRepositoryExists
+		}
+		if !repo.KeepSecrets(set.Repositories[i]) {
+			return &ValidationError{Err: errors.New("webhook_secrets: hidden secret (***) has no stored value at the same position")}
+		}
+		set.Repositories[i] = repo
+		return nil
+	}, func() error {
+		return s.registry.Update(ctx, key, repo)
+	})
+}
+
+// SetEnabled включает или отключает доставку по записи и возвращает обновлённую запись
+func (s *RepositoryService) SetEnabled(ctx context.Context, key domain.RouteKey, enabled bool) (domain.Repository, error) {
+	action := "отключён"
+	if enabled {
+		action = "включён"
+	}
+
+	// Запись читается и изменяется под одной блокировкой, иначе параллельное изменение потерялось бы
+	var repo domain.Repository
+	err := s.change(ctx, action, key, func(set *domain.RepositorySet) error {
+		i := set.Index(key)
+		if i < 0 {
+			return domain.ErrRepositoryNotFound
+		}
+		set.Repositories[i].Enabled = enabled
+		repo = set.Repositories[i]
+		return nil
+	}, func() error {
+		return s.registry.Update(ctx, key, repo)
+	})
+	if err != nil {
+		return domain.Repository{}, err
+	}
+	return repo, nil
+}
+
+// Delete удаляет запись репозитория
+func (s *RepositoryService) Delete(ctx context.Context, key domain.RouteKey) error {
+	return s.change(ctx, "удалён", key, func(set *domain.RepositorySet) error {
+		i := set.Index(key)
+		if i < 0 {
+			return domain.ErrRepositoryNotFound
+		}
+		set.Repositories = slices.Delete(set.Repositories, i, i+1)
+		return nil
+	}, func() error {
+		return s.registry.Delete(ctx, key)
+	})
+}
+
+// SetDefaultRoute задаёт маршрут по умолчанию; nil удаляет его
+func (s *RepositoryService) SetDefaultRoute(ctx context.Context, route *domain.Repository) error {
+	return s.change(ctx, "изменён", domain.RouteKey{}, func(set *domain.RepositorySet) error {
+		set.DefaultRoute = route
+		return nil
+	}, func() error {
+		return s.registry.SetDefaultRoute(ctx, route)
+	})
+}
+
+// change применяет modify к текущей конфигурации, проверяет результат, сохраняет изменение
+// через store и обновляет таблицу маршрутизации
+func (s *RepositoryService) change(ctx context.Context, action string, key domain.RouteKey,
+	modify func(*domain.RepositorySet) error, store func() error,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set, err := s.registry.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := modify(&set); err != nil {
+		return err
+	}
+	if err := s.validate(set); err != nil {
+		return &ValidationError{Err: err}
+	}
+
+	if err := store(); err != nil {
+		return err
+	}
+	if err := s.apply(set); err != nil {
+		return err
+	}
+
+	if key == (domain.RouteKey{}) {
+		log.Printf("🗂️ Маршрут по умолчанию %s", action)
+	} else {
+		log.Printf("🗂️ Репозиторий %s %s", key, action)
+	}
+	return nil
+}
+
+func (s *RepositoryService) apply(set domain.RepositorySet) error {
+	routing, err := NewRoutingTable(set.Repositories, set.DefaultRoute)
+	if err != nil {
+		return fmt.Errorf("failed to build routing table: %w", err)
+	}
+
+	s.notifier.SetRouting(routing)
+	return nil
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/pkg/metrics"
+)
+
+var retentionDeleted = metrics.NewCounterVec(
+	"tgbot_retention_deleted_total",
+	"Stored rows removed by retention, by data type",
+	"data",
+)
+
+// RetentionPolicy - срок хранения одного вида данных
+type RetentionPolicy struct {
+	// Data - название данных в логах и метке метрики: events, outbox, dead_letters, threads
+	Data string
+	// MaxAge - сколько хранить данные; 0 - бессрочно
+	MaxAge time.Duration
+	// Delete удаляет данные старше before и возвращает количество удалённых записей
+	Delete func(ctx context.Context, before time.Time) (int, error)
+}
+
+// Retention периодически удаляет данные старше срока хранения и уплотняет хранилище,
+// чтобы история, outbox и недоставленные сообщения не росли без ограничений.
+// Перед удалением данные можно выгрузить командой export.
+type Retention struct {
+	policies []RetentionPolicy
+	// compact вызывается после удаления, если что-то было удалено
+	compact func(ctx context.Context) error
+	now     func() time.Time
+}
+
+func NewRetention(policies []RetentionPolicy, compact func(ctx context.Context) error) *Retention {
+	return &Retention{
+		policies: policies,
+		compact:  compact,
+		now:      time.Now,
+	}
+}
+
+// Run очищает данные сразу после запуска и затем каждые interval до отмены контекста
+func (r *Retention) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.Cleanup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cleanup удаляет устаревшие данные по всем политикам и возвращает количество удалённых записей.
+// Ошибка одной политики не мешает остальным.
+func (r *Retention) Cleanup(ctx context.Context) int {
+	now := r.now()
+
+	total := 0
+	for _, policy := range r.policies {
+		if policy.MaxAge <= 0 {
+			continue
+		}
+
+		n, err := policy.Delete(ctx, now.Add(-policy.MaxAge))
+		if n > 0 {
+			retentionDeleted.Add(policy.Data, int64(n))
+			log.Printf("🧹 Удалено записей %s старше %s: %d", policy.Data, policy.MaxAge, n)
+		}
+		if err != nil {
+			log.Printf("❌ Не удалось удалить устаревшие записи %s: %v", policy.Data, err)
+		}
+		total += n
+	}
+
+	if total > 0 && r.compact != nil && ctx.Err() == nil {
+		if err := r.compact(ctx); err != nil {
+			log.Printf("❌ Не удалось уплотнить хранилище: %v", err)
+		}
+	}
+
+	return total
+}
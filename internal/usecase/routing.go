@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/pkg/expr"
+)
+
+// Route - маршрут доставки: запись репозитория со скомпилированным условием
+type Route struct {
+	Repository domain.Repository
+	condition  *expr.Program
+}
+
+// RouteDecision - результат проверки события по одному маршруту
+type RouteDecision struct {
+	Repository domain.Repository `json:"repository"`
+	Matched    bool              `json:"matched"`
+	// CatchAll - решение принято по маршруту по умолчанию для неизвестного проекта
+	CatchAll bool   `json:"catch_all,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// Files - файлы пуша, прошедшие фильтр paths
+	Files []string `json:"files,omitempty"`
+}
+
+// RoutingTable определяет, в какие чаты доставлять событие
+type RoutingTable struct {
+	routes   []Route
+	projects map[domain.ProjectKey]struct{}
+	// defaultRoute - маршрут для проектов, отсутствующих в конфигурации (может быть nil)
+	defaultRoute *Route
+}
+
+// NewRoutingTable компилирует условия всех репозиториев.
+// defaultRoute - необязательный маршрут для событий неизвестных проектов.
+func NewRoutingTable(repositories []domain.Repository, defaultRoute *domain.Repository) (*RoutingTable, error) {
+	table := &RoutingTable{
+		routes:   make([]Route, 0, len(repositories)),
+		projects: make(map[domain.ProjectKey]struct{}, len(repositories)),
+	}
+
+	for _, repo := range repositories {
+		route, err := newRoute(repo)
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: %w", repo.ID, err)
+		}
+		table.routes = append(table.routes, route)
+		table.projects[repo.Key()] = struct{}{}
+	}
+
+	if defaultRoute != nil {
+		route, err := newRoute(*defaultRoute)
+		if err != nil {
+			return nil, fmt.Errorf("default route: %w", err)
+		}
+		table.defaultRoute = &route
+	}
+
+	return table, nil
+}
+
+func newRoute(repo domain.Repository) (Route, error) {
+	route := Route{Repository: repo}
+
+	if repo.Condition != "" {
+		program, err := domain.CompileCondition(repo.Condition)
+		if err != nil {
+			return Route{}, fmt.Errorf("invalid condition: %w", err)
+		}
+		route.condition = program
+	}
+
+	return route, nil
+}
+
+// Known проверяет, есть ли проект в конфигурации
+func (t *RoutingTable) Known(key domain.ProjectKey) bool {
+	_, ok := t.projects[key]
+	return ok
+}
+
+// WebhookSecrets возвращает секреты вебхука проекта, действующие в момент now.
+// ok == false, если у проекта нет собственных секретов и должен использоваться общий.
+// Если проект описан несколькими записями (несколько чатов), секреты объединяются.
+func (t *RoutingTable) WebhookSecrets(key domain.ProjectKey, now time.Time) (secrets []string, ok bool) {
+	for i := range t.routes {
+		repo := &t.routes[i].Repository
+		if repo.Key() != key || !repo.HasWebhookSecrets() {
+			continue
+		}
+		ok = true
+		secrets = append(secrets, repo.ActiveWebhookSecrets(now)...)
+	}
+	return secrets, ok
+}
+
+// Match возвращает маршруты, по которым нужно доставить событие
+func (t *RoutingTable) Match(event *domain.Event) []RouteDecision {
+	var matched []RouteDecision
+	for _, decision := range t.Explain(event) {
+		if decision.Matched {
+			matched = append(matched, decision)
+		}
+	}
+	return matched
+}
+
+// Explain проверяет событие по всем маршрутам проекта и объясняет каждое решение.
+// Для неизвестного проекта используется маршрут по умолчанию, если он задан.
+func (t *RoutingTable) Explain(event *domain.Event) []RouteDecision {
+	decisions := make([]RouteDecision, 0)
+
+	key := event.Key()
+
+	if !t.Known(key) {
+		if t.defaultRoute != nil {
+			decision := t.defaultRoute.decide(event)
+			decision.CatchAll = true
+			decisions = append(decisions, decision)
+		}
+		return decisions
+	}
+
+	for i := range t.routes {
+		route := &t.routes[i]
+		if route.Repository.Key() != key {
+			continue
+		}
+		decisions = append(decisions, route.decide(event))
+	}
+
+	return decisions
+}
+
+func (r *Route) decide(event *domain.Event) RouteDecision {
+	repo := r.Repository
+	// Решение отдаётся в dry-run API, поэтому секреты вебхука в него не попадают
+	decision := RouteDecision{Repository: repo.WithoutSecrets()}
+
+	if !repo.IsEnabled() {
+		decision.Reason = "repository is disabled"
+		return decision
+	}
+
+	// У issue и комментариев вне merge request ветки нет - фильтр по веткам к ним не применяется
+	if event.Ref != "" && !repo.HasBranch(event.Ref) {
+		decision.Reason = fmt.Sprintf("branch %q is not in %v", event.Ref, repo.Branches)
+		return decision
+	}
+
+	// Фильтр по путям применяется только к push-событиям: у остальных нет списка файлов
+	if event.Kind == domain.EventKindPush {
+		decision.Files = repo.MatchFiles(event.Files())
+		if repo.HasPathFilter() && len(decision.Files) == 0 {
+			decision.Reason = fmt.Sprintf("no changed files match paths %v", repo.Paths)
+			return decision
+		}
+	}
+
+	if r.condition != nil {
+		ok, err := r.condition.Eval(event.Env())
+		if err != nil {
+			decision.Reason = "condition error: " + err.Error()
+			return decision
+		}
+		if !ok {
+			decision.Reason = "condition is false"
+			return decision
+		}
+	}
+
+	decision.Matched = true
+	return decision
+}
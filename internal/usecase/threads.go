@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// ThreadStore - постоянное хранилище соответствия "чат + объект GitLab -> первое сообщение о нём"
+type ThreadStore interface {
+	// Get возвращает ID сообщения, если соответствие есть и не истекло к моменту now
+	Get(ctx context.Context, chatID, thread string, now time.Time) (int, bool, error)
+	// Put сохраняет соответствие до expiresAt, заменяя существующее
+	Put(ctx context.Context, chatID, thread string, messageID int, expiresAt time.Time) error
+	// DeleteExpired удаляет истёкшие соответствия и возвращает их количество
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// ThreadSender отправляет уведомления об одном объекте GitLab (merge request, issue, пайплайн) ответами
+// на первое сообщение о нём в чате, чтобы в загруженных чатах каждый merge request читался
+// отдельной веткой. Соответствие продлевается на ttl при каждом новом сообщении в ветке.
+type ThreadSender struct {
+	next  MessageSender
+	store ThreadStore
+	ttl   time.Duration
+	now   func() time.Time
+
+	// locks - блокировки веток: пока первое сообщение ветки не сохранено, второе сообщение
+	// той же ветки (например, из тихих часов) не должно стать ещё одним первым сообщением
+	mu    sync.Mutex
+	locks map[threadKey]*threadLock
+}
+
+type threadKey struct {
+	chatID string
+	thread string
+}
+
+type threadLock struct {
+	mu sync.Mutex
+	// refs - сколько отправок ждут или держат блокировку; при нуле она удаляется
+	refs int
+}
+
+func NewThreadSender(next MessageSender, store ThreadStore, ttl time.Duration) *ThreadSender {
+	return &ThreadSender{
+		next:  next,
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+		locks: make(map[threadKey]*threadLock),
+	}
+}
+
+// lock захватывает блокировку ветки и возвращает функцию её освобождения
+func (s *ThreadSender) lock(key threadKey) func() {
+	s.mu.Lock()
+	l, ok := s.locks[key]
+	if !ok {
+		l = &threadLock{}
+		s.locks[key] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		s.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.locks, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *ThreadSender) SendMessage(ctx context.Context, n domain.Notification) error {
+	if n.Thread == "" || n.ReplyToMessageID != 0 {
+		return s.next.SendMessage(ctx, n)
+	}
+
+	// Поиск ветки, отправка и сохранение первого сообщения выполняются под блокировкой ветки
+	// (отправитель вызывает Sent до возврата из SendMessage)
+	defer s.lock(threadKey{chatID: n.ChatID, thread: n.Thread})()
+
+	rootID, found, err := s.store.Get(ctx, n.ChatID, n.Thread, s.now())
+	if err != nil {
+		// Без ветки сообщение всё равно полезно - отправляем отдельным
+		log.Printf("⚠️ Не удалось найти ветку %s в чате %s: %v", n.Thread, n.ChatID, err)
+	}
+	if found {
+		n.ReplyToMessageID = rootID
+	}
+
+	sent := n.Sent
+	n.Sent = func(messageID int) {
+		if !found {
+			rootID = messageID
+		}
+		if err := s.store.Put(context.WithoutCancel(ctx), n.ChatID, n.Thread, rootID, s.now().Add(s.ttl)); err != nil {
+			log.Printf("⚠️ Не удалось сохранить ветку %s в чате %s: %v", n.Thread, n.ChatID, err)
+		}
+		if sent != nil {
+			sent(messageID)
+		}
+	}
+
+	return s.next.SendMessage(ctx, n)
+}
+
+// Run периодически удаляет истёкшие соответствия до отмены контекста
+func (s *ThreadSender) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.store.DeleteExpired(ctx, s.now())
+			if err != nil {
+				log.Printf("❌ Не удалось удалить истёкшие ветки сообщений: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("🧵 Удалено истёкших веток сообщений: %d", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// UnknownProjects учитывает события от проектов, отсутствующих в конфигурации.
+// Это помогает заметить, что вебхук в GitLab настроен, а запись в repositories.json забыта.
+type UnknownProjects struct {
+	mu       sync.Mutex
+	projects map[domain.ProjectKey]*domain.UnknownProject
+	now      func() time.Time
+}
+
+func NewUnknownProjects() *UnknownProjects {
+	return &UnknownProjects{
+		projects: make(map[domain.ProjectKey]*domain.UnknownProject),
+		now:      time.Now,
+	}
+}
+
+// Record учитывает событие от неизвестного проекта
+func (u *UnknownProjects) Record(event *domain.Event) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := u.now()
+
+	key := event.Key()
+
+	project, ok := u.projects[key]
+	if !ok {
+		project = &domain.UnknownProject{Instance: key.Instance, ProjectID: key.ProjectID, FirstSeen: now}
+		u.projects[key] = project
+	}
+
+	project.ProjectName = event.ProjectName
+	project.Count++
+	project.LastSeen = now
+	project.LastKind = event.Kind
+}
+
+// List возвращает неизвестные проекты, начиная с недавно замеченных
+func (u *UnknownProjects) List() []domain.UnknownProject {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	list := make([]domain.UnknownProject, 0, len(u.projects))
+	for _, project := range u.projects {
+		list = append(list, *project)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastSeen.After(list[j].LastSeen)
+	})
+
+	return list
+}
+
+// Forget удаляет проект из списка и сообщает, был ли он там
+func (u *UnknownProjects) Forget(key domain.ProjectKey) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	_, ok := u.projects[key]
+	delete(u.projects, key)
+	return ok
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// Задержка перед повтором getUpdates после ошибки; удваивается до максимума
+const (
+	pollBackoffMin = 5 * time.Second
+	pollBackoffMax = time.Minute
+)
+
+// UpdateSource - источник обновлений Telegram (long polling)
+type UpdateSource interface {
+	GetUpdates(ctx context.Context, offset int, timeout time.Duration) ([]domain.Update, error)
+}
+
+// UpdateOffsetStore хранит номер следующего необработанного обновления между перезапусками
+type UpdateOffsetStore interface {
+	UpdateOffset(ctx context.Context) (int, error)
+	SetUpdateOffset(ctx context.Context, offset int) error
+}
+
+// UpdatePoller получает обновления через getUpdates и передаёт их маршрутизатору команд
+type UpdatePoller struct {
+	source  UpdateSource
+	offsets UpdateOffsetStore
+	router  *CommandRouter
+	timeout time.Duration
+}
+
+// NewUpdatePoller создаёт получатель обновлений; timeout - время ожидания одного запроса getUpdates
+func NewUpdatePoller(source UpdateSource, offsets UpdateOffsetStore, router *CommandRouter, timeout time.Duration) *UpdatePoller {
+	return &UpdatePoller{
+		source:  source,
+		offsets: offsets,
+		router:  router,
+		timeout: timeout,
+	}
+}
+
+// Run получает и обрабатывает обновления до отмены контекста. Номер следующего обновления
+// сохраняется после каждой пачки: после перезапуска обработка продолжается с него.
+func (p *UpdatePoller) Run(ctx context.Context) {
+	offset, err := p.offsets.UpdateOffset(ctx)
+	if err != nil {
+		// Без сохранённого номера Telegram отдаст все неподтверждённые обновления
+		log.Printf("⚠️ Не удалось прочитать номер обновления Telegram: %v", err)
+	}
+
+	log.Printf("📡 Получение команд Telegram (long polling) запущено")
+
+	backoff := pollBackoffMin
+	for ctx.Err() == nil {
+		updates, err := p.source.GetUpdates(ctx, offset, p.timeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("❌ Не удалось получить обновления Telegram, повтор через %s: %v", backoff, err)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, pollBackoffMax)
+			continue
+		}
+		backoff = pollBackoffMin
+
+		if len(updates) == 0 {
+			continue
+		}
+
+		for _, update := range updates {
+			p.router.Dispatch(ctx, update)
+			offset = max(offset, update.ID+1)
+		}
+
+		// Номер сохраняется и во время остановки, чтобы обработанные команды не повторились
+		if err := p.offsets.SetUpdateOffset(context.WithoutCancel(ctx), offset); err != nil {
+			log.Printf("⚠️ Не удалось сохранить номер обновления Telegram: %v", err)
+		}
+	}
+
+	log.Printf("📡 Получение команд Telegram остановлено")
+}
@@ -1,10 +1,12 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,74 +16,375 @@ import (
 )
 
 type Config struct {
-	Server       ServerConfig   `mapstructure:"server"`
-	GitLab       GitLabConfig   `mapstructure:"gitlab"`
-	Telegram     TelegramConfig `mapstructure:"telegram"`
-	LogLevel     string         `mapstructure:"log_level"`
+	Server       ServerConfig       `mapstructure:"server"`
+	GitLab       GitLabConfig       `mapstructure:"gitlab"`
+	Telegram     TelegramConfig     `mapstructure:"telegram"`
+	QuietHours   QuietHoursConfig   `mapstructure:"quiet_hours"`
+	Delivery     DeliveryConfig     `mapstructure:"delivery"`
+	Idempotency  IdempotencyConfig  `mapstructure:"idempotency"`
+	Outbox       OutboxConfig       `mapstructure:"outbox"`
+	DeadLetters  DeadLettersConfig  `mapstructure:"dead_letters"`
+	History      HistoryConfig      `mapstructure:"history"`
+	Threads      ThreadsConfig      `mapstructure:"threads"`
+	Retention    RetentionConfig    `mapstructure:"retention"`
+	ChatSettings ChatSettingsConfig `mapstructure:"chat_settings"`
+	Priorities   PriorityConfig     `mapstructure:"priorities"`
+	Admin        AdminConfig        `mapstructure:"admin"`
+	Secrets      SecretsConfig      `mapstructure:"secrets"`
+	Registry     RegistryConfig     `mapstructure:"registry"`
+	Storage      StorageConfig      `mapstructure:"storage"`
+	LogLevel     string             `mapstructure:"log_level"`
 	Repositories []domain.Repository
+	// RepositoriesPath - путь к файлу, из которого загружены репозитории
+	RepositoriesPath string
+	// DefaultRoute - маршрут для событий проектов, отсутствующих в repositories.json
+	DefaultRoute *domain.Repository
+	// SecretFiles - ключи конфигурации, значения которых загружены из файлов (<KEY>_FILE), и пути к файлам
+	SecretFiles map[string]string
+	// Sources - источник каждого ключа конфигурации: файл, переменная окружения или значение по умолчанию
+	Sources map[string]string
 }
 
 type ServerConfig struct {
+	// Host - адрес для прослушивания; пустое значение - все интерфейсы
+	Host              string        `mapstructure:"host"`
 	Port              int           `mapstructure:"port"`
 	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
 	Shutdown          time.Duration `mapstructure:"shutdown_timeout"`
 	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
 	CompressSize      int           `mapstructure:"compress_size"`
+	// UnixSocket - путь к unix-сокету; если задан, сервер слушает его вместо host:port
+	UnixSocket string `mapstructure:"unix_socket"`
+	// UnixSocketMode - права на файл сокета в восьмеричной записи, например "0660"
+	UnixSocketMode string          `mapstructure:"unix_socket_mode"`
+	TLS            ServerTLSConfig `mapstructure:"tls"`
 }
 
-type GitLabConfig struct {
-	WebhookSecret string `mapstructure:"webhook_secret"`
+// ServerTLSConfig - сертификат и ключ для HTTPS; при изменении файлов перечитываются без перезапуска
+type ServerTLSConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+// Enabled проверяет, включён ли TLS
+func (c ServerTLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// SocketMode возвращает права на файл unix-сокета (по умолчанию 0660)
+func (c ServerConfig) SocketMode() (os.FileMode, error) {
+	if c.UnixSocketMode == "" {
+		return 0o660, nil
+	}
+
+	mode, err := strconv.ParseUint(c.UnixSocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid server.unix_socket_mode %q: expected octal permissions like 0660", c.UnixSocketMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// Хранилища реестра репозиториев
+const (
+	RegistryFile   = "file"
+	RegistrySQLite = "sqlite"
+)
+
+// RegistryConfig - откуда берётся конфигурация репозиториев: repositories.json
+// или база данных с управлением через /admin/repositories
+type RegistryConfig struct {
+	Backend string `mapstructure:"backend"`
+}
+
+// StorageConfig - локальная база данных бота
+type StorageConfig struct {
+	SQLitePath string `mapstructure:"sqlite_path"`
 }
 
 type TelegramConfig struct {
-	BotToken   string        `mapstructure:"bot_token"`
-	Timeout    time.Duration `mapstructure:"timeout"`
-	MaxRetries int           `mapstructure:"max_retries"`
+	BotToken domain.Secret `mapstructure:"bot_token"`
+	// APIURL - адрес Bot API; меняется для локального Bot API сервера или тестовой заглушки
+	APIURL     string                `mapstructure:"api_url"`
+	Timeout    time.Duration         `mapstructure:"timeout"`
+	MaxRetries int                   `mapstructure:"max_retries"`
+	Updates    TelegramUpdatesConfig `mapstructure:"updates"`
+}
+
+// Способы получения обновлений (команд) от Telegram
+const (
+	UpdatesOff     = "off"
+	UpdatesPolling = "polling"
+	UpdatesWebhook = "webhook"
+)
+
+// TelegramUpdatesConfig - получение команд бота из Telegram
+type TelegramUpdatesConfig struct {
+	// Mode - off (бот только отправляет уведомления), polling (long polling через getUpdates)
+	// или webhook (Telegram сам отправляет обновления на /webhook/telegram)
+	Mode string `mapstructure:"mode"`
+	// PollTimeout - сколько Telegram держит запрос getUpdates открытым в ожидании обновлений
+	PollTimeout time.Duration         `mapstructure:"poll_timeout"`
+	Webhook     TelegramWebhookConfig `mapstructure:"webhook"`
+}
+
+// TelegramWebhookConfig - приём обновлений вебхуком (telegram.updates.mode: webhook)
+type TelegramWebhookConfig struct {
+	// URL - публичный HTTPS-адрес /webhook/telegram этого сервера
+	URL string `mapstructure:"url"`
+	// SecretToken - секрет из заголовка X-Telegram-Bot-Api-Secret-Token: 1-256 символов A-Z, a-z, 0-9, _ и -
+	SecretToken domain.Secret `mapstructure:"secret_token"`
+	// Register - регистрировать вебхук (setWebhook) при запуске; false - вебхук настроен вручную
+	Register bool `mapstructure:"register"`
+	// DeleteOnShutdown - удалять вебхук (deleteWebhook) при остановке
+	DeleteOnShutdown bool `mapstructure:"delete_on_shutdown"`
+}
+
+// SecretsConfig - параметры секретов, загружаемых из файлов
+type SecretsConfig struct {
+	// ReloadInterval - период перечитывания файлов секретов для ротации без перезапуска (0 - отключено)
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+}
+
+// AdminConfig - доступ к административному API (/admin); пустой токен отключает API
+type AdminConfig struct {
+	Token domain.Secret `mapstructure:"token"`
+}
+
+// Хранилища очереди доставки
+const (
+	QueueMemory = "memory"
+	QueueWAL    = "wal"
+)
+
+// Политики сброса журнала очереди на диск
+const (
+	SyncAlways   = "always"
+	SyncInterval = "interval"
+	SyncNever    = "never"
+)
+
+// DeliveryConfig - параметры очереди доставки уведомлений
+type DeliveryConfig struct {
+	Workers   int `mapstructure:"workers"`
+	QueueSize int `mapstructure:"queue_size"`
+	// Backend - где хранится очередь: memory (теряется при остановке) или wal (журнал на диске)
+	Backend string         `mapstructure:"backend"`
+	WAL     QueueWALConfig `mapstructure:"wal"`
+}
+
+// QueueWALConfig - журнал очереди доставки на диске (delivery.backend: wal)
+type QueueWALConfig struct {
+	Dir string `mapstructure:"dir"`
+	// SegmentSize - размер файла журнала в байтах, после которого начинается новый
+	SegmentSize int64 `mapstructure:"segment_size"`
+	// Sync - когда журнал сбрасывается на диск: always, interval или never
+	Sync         string        `mapstructure:"sync"`
+	SyncInterval time.Duration `mapstructure:"sync_interval"`
+}
+
+// IdempotencyConfig - защита от повторной обработки вебхуков с тем же X-Gitlab-Event-UUID или Idempotency-Key
+type IdempotencyConfig struct {
+	// TTL - сколько помнить обработанный ключ (0 - проверка отключена)
+	TTL        time.Duration `mapstructure:"ttl"`
+	MaxEntries int           `mapstructure:"max_entries"`
+}
+
+// Enabled сообщает, включена ли проверка повторов
+func (c IdempotencyConfig) Enabled() bool {
+	return c.TTL > 0
+}
+
+// OutboxConfig - сохранение принятых событий в базе до доставки уведомлений
+type OutboxConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// RetryInterval - базовая пауза перед повтором, если уведомления не удалось поставить в очередь
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
+}
+
+// DeadLettersConfig - сохранение уведомлений, не доставленных после всех попыток
+type DeadLettersConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// HistoryConfig - история обработанных событий и итогов доставки (/admin/events)
+type HistoryConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// ChatSettingsConfig - настройки чатов (язык, часовой пояс, подробность, заглушение) в базе
+// с управлением через /admin/chat-settings
+type ChatSettingsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// ThreadsConfig - отправка уведомлений об одном merge request или пайплайне ответами
+// на первое сообщение о нём
+type ThreadsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TTL - сколько помнить ветку после последнего сообщения в ней
+	TTL time.Duration `mapstructure:"ttl"`
 }
 
-func Load() (*Config, error) {
+// RetentionConfig - сроки хранения данных в базе; 0 - хранить бессрочно.
+// Ветки сообщений удаляются по threads.ttl, в том числе при отключённых ветках.
+type RetentionConfig struct {
+	// Interval - как часто удалять устаревшие данные
+	Interval time.Duration `mapstructure:"interval"`
+	// Events - история событий (по времени получения)
+	Events time.Duration `mapstructure:"events"`
+	// Outbox - доставленные события outbox (по времени доставки)
+	Outbox time.Duration `mapstructure:"outbox"`
+	// DeadLetters - недоставленные сообщения (по времени сохранения)
+	DeadLetters time.Duration `mapstructure:"dead_letters"`
+	// Vacuum - перестраивать файл базы после удаления, возвращая место на диске
+	Vacuum bool `mapstructure:"vacuum"`
+}
+
+// PriorityConfig - правила определения приоритета уведомлений
+type PriorityConfig struct {
+	Default  string               `mapstructure:"default"`
+	Rules    []PriorityRuleConfig `mapstructure:"rules"`
+	Critical CriticalConfig       `mapstructure:"critical"`
+}
+
+type PriorityRuleConfig struct {
+	Condition string `mapstructure:"condition"`
+	Priority  string `mapstructure:"priority"`
+}
+
+// CriticalConfig - поведение для критичных уведомлений
+type CriticalConfig struct {
+	Pin     bool   `mapstructure:"pin"`
+	Mention string `mapstructure:"mention"` // например, "@oncall"
+}
+
+// DefaultPriority возвращает приоритет по умолчанию (normal, если не задан)
+func (c PriorityConfig) DefaultPriority() (domain.Priority, error) {
+	if c.Default == "" {
+		return domain.PriorityNormal, nil
+	}
+	return domain.ParsePriority(c.Default)
+}
+
+// QuietHoursConfig - тихие часы по чатам (ключ - telegram_channel_id)
+type QuietHoursConfig struct {
+	StoragePath   string                        `mapstructure:"storage_path"`
+	CheckInterval time.Duration                 `mapstructure:"check_interval"`
+	Chats         map[string]ChatScheduleConfig `mapstructure:"chats"`
+}
+
+type ChatScheduleConfig struct {
+	Timezone string              `mapstructure:"timezone"`
+	Mode     string              `mapstructure:"mode"` // silent или hold
+	Windows  []QuietWindowConfig `mapstructure:"windows"`
+	// WeekendDays - дни, целиком считающиеся тихими: [sat, sun]
+	WeekendDays []string `mapstructure:"weekend_days"`
+}
+
+type QuietWindowConfig struct {
+	Start string `mapstructure:"start"` // "22:00"
+	End   string `mapstructure:"end"`   // "08:00"
+}
+
+// Schedules преобразует конфигурацию в расписания тихих часов по чатам
+func (c QuietHoursConfig) Schedules() (map[string]domain.QuietSchedule, error) {
+	schedules := make(map[string]domain.QuietSchedule, len(c.Chats))
+
+	for chatID, chat := range c.Chats {
+		location, err := time.LoadLocation(chat.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("chat %s: invalid timezone %q: %w", chatID, chat.Timezone, err)
+		}
+
+		mode := domain.QuietMode(chat.Mode)
+		if mode == "" {
+			mode = domain.QuietModeSilent
+		}
+		if mode != domain.QuietModeSilent && mode != domain.QuietModeHold {
+			return nil, fmt.Errorf("chat %s: invalid mode %q (expected silent or hold)", chatID, chat.Mode)
+		}
+
+		schedule := domain.QuietSchedule{Location: location, Mode: mode}
+
+		for i, w := range chat.Windows {
+			window, err := domain.ParseQuietWindow(w.Start, w.End)
+			if err != nil {
+				return nil, fmt.Errorf("chat %s: window %d: %w", chatID, i, err)
+			}
+			schedule.Windows = append(schedule.Windows, window)
+		}
+
+		for _, name := range chat.WeekendDays {
+			day, err := domain.ParseWeekday(strings.ToLower(name))
+			if err != nil {
+				return nil, fmt.Errorf("chat %s: %w", chatID, err)
+			}
+			schedule.WeekendDays = append(schedule.WeekendDays, day)
+		}
+
+		schedules[chatID] = schedule
+	}
+
+	return schedules, nil
+}
+
+func Load(opts Options) (*Config, error) {
 	// 1. Загружаем .env файлы (godotenv)
 	if err := loadEnvFiles(); err != nil {
 		log.Printf("Warning: %v", err)
 	}
 
 	v := viper.New()
-	// Имя файла без расширения (будет искать config.yaml, config.yml, config.json и т.д.)
-	v.SetConfigName("config")
 	// Тип конфигурационного файла (yaml, json, toml и т.д.)
 	v.SetConfigType("yaml")
-	v.AddConfigPath("./config") // Относительный путь от корня проекта
-	v.AddConfigPath(".")        // Текущая директория (fallback)
+	// Префикс для env переменных (TGBOT -> TGBOT_SERVER_PORT, TGBOT_LOG_LEVEL и т.д.)
+	if opts.EnvPrefix != "" {
+		v.SetEnvPrefix(opts.EnvPrefix)
+	}
 	v.AutomaticEnv()
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
-	// v.AddConfigPath("/etc/tgGitlabBot/")  // Системная директория (для production)
-	// v.AddConfigPath("$HOME/.tgGitlabBot") // Home directory пользователя (опционально)
-	// Префикс для env переменных (TGBOT_SERVER_PORT, TGBOT_LOG_LEVEL и т.д.)
-	// v.SetEnvPrefix("TGBOT")
+	// Значения по умолчанию, если ключ не задан ни в файлах, ни в env
+	setDefaults(v)
 
-	// Явная привязка вложенных полей к env переменным
-	// Формат: BindEnv("yaml.путь.к.полю", "ENV_VARIABLE_NAME")
-	// v.BindEnv("server.port", "SERVER_PORT")
+	files, err := opts.configFiles()
+	if err != nil {
+		return nil, fmt.Errorf("config file not found: %w", err)
+	}
 
-	// Устанавливаем дефолтные значения, если не найдены в файле или env
-	// v.SetDefault("server.port", 8080)
+	// Слои конфигурации накладываются по порядку: /etc, $HOME, ., ./config (или только --config)
+	sources := newSources(opts.EnvPrefix)
+	for _, path := range files {
+		if err := sources.mergeLayer(v, path); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+	log.Printf("📄 Config files: %v", files)
 
-	if err := v.ReadInConfig(); err != nil {
-		// Если файл не найден - это критическая ошибка
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, fmt.Errorf("config file not found: %w", err)
+	// Значения из файлов по переменным <KEY>_FILE (Docker/Kubernetes secrets)
+	secretFiles, err := applyFileOverrides(v, opts.EnvPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secrets from files: %w", err)
+	}
+	// Значения, целиком подставленные из ${VAR} при заданной VAR_FILE, тоже отслеживаются для ротации
+	for key, file := range sources.secretFiles {
+		if _, ok := secretFiles[key]; !ok {
+			secretFiles[key] = file.path
 		}
-		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.SecretFiles = secretFiles
+	cfg.Sources = sources.resolve(v, secretFiles)
 
-	if err := loadRepositories(&cfg); err != nil {
+	// При хранении в базе repositories.json необязателен: он используется только для первичного импорта
+	optional := cfg.Registry.Backend == RegistrySQLite
+	if err := loadRepositories(&cfg, opts.repositoriesFiles(files), optional); err != nil {
 		return nil, fmt.Errorf("failed to load repositories: %w", err)
 	}
 
@@ -89,11 +392,71 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	logSources(cfg.Sources)
 	log.Printf("Configuration loaded successfully: %d repositories", len(cfg.Repositories))
 
 	return &cfg, nil
 }
 
+// setDefaults задаёт значения по умолчанию: config.yaml может содержать только отличающиеся ключи
+func setDefaults(v *viper.Viper) {
+	// Секреты регистрируются явно: иначе при --config без этих ключей
+	// переменные <KEY>_FILE не попадают в AllKeys и молча игнорируются
+	v.SetDefault("telegram.bot_token", "")
+	v.SetDefault("gitlab.webhook_secret", "")
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.read_timeout", "10s")
+	v.SetDefault("server.write_timeout", "10s")
+	v.SetDefault("server.idle_timeout", "60s")
+	v.SetDefault("server.shutdown_timeout", "10s")
+	v.SetDefault("server.read_header_timeout", "5s")
+	v.SetDefault("server.compress_size", 5)
+	v.SetDefault("server.unix_socket_mode", "0660")
+	v.SetDefault("telegram.api_url", "https://api.telegram.org")
+	v.SetDefault("telegram.timeout", "10s")
+	v.SetDefault("telegram.max_retries", 3)
+	v.SetDefault("telegram.updates.mode", UpdatesOff)
+	v.SetDefault("telegram.updates.poll_timeout", "30s")
+	v.SetDefault("telegram.updates.webhook.url", "")
+	v.SetDefault("telegram.updates.webhook.secret_token", "")
+	v.SetDefault("telegram.updates.webhook.register", true)
+	v.SetDefault("telegram.updates.webhook.delete_on_shutdown", false)
+	v.SetDefault("secrets.reload_interval", "1m")
+	v.SetDefault("delivery.workers", 2)
+	v.SetDefault("delivery.queue_size", 1000)
+	v.SetDefault("delivery.backend", QueueMemory)
+	v.SetDefault("delivery.wal.dir", "./data/queue")
+	v.SetDefault("delivery.wal.segment_size", 16<<20)
+	v.SetDefault("delivery.wal.sync", SyncInterval)
+	v.SetDefault("delivery.wal.sync_interval", "1s")
+	v.SetDefault("idempotency.ttl", "24h")
+	v.SetDefault("idempotency.max_entries", 100000)
+	// Функции, требующие базы storage.sqlite_path, по умолчанию выключены, как и telegram.updates
+	v.SetDefault("outbox.enabled", false)
+	v.SetDefault("outbox.retry_interval", "10s")
+	v.SetDefault("dead_letters.enabled", false)
+	v.SetDefault("history.enabled", false)
+	v.SetDefault("threads.enabled", false)
+	v.SetDefault("chat_settings.enabled", false)
+	v.SetDefault("threads.ttl", "720h")
+	v.SetDefault("retention.interval", "1h")
+	v.SetDefault("retention.events", "2160h")
+	v.SetDefault("retention.outbox", "168h")
+	v.SetDefault("retention.dead_letters", "2160h")
+	v.SetDefault("priorities.default", "normal")
+	v.SetDefault("quiet_hours.storage_path", "./data/held_messages.json")
+	v.SetDefault("quiet_hours.check_interval", "1m")
+	v.SetDefault("registry.backend", RegistryFile)
+	v.SetDefault("storage.sqlite_path", "./data/tgbot.db")
+	v.SetDefault("log_level", "info")
+}
+
+// UsesDatabase сообщает, нужна ли локальная база данных (storage.sqlite_path)
+func (c *Config) UsesDatabase() bool {
+	return c.Registry.Backend == RegistrySQLite || c.Outbox.Enabled || c.DeadLetters.Enabled || c.History.Enabled ||
+		c.Threads.Enabled || c.ChatSettings.Enabled || c.Telegram.Updates.Mode == UpdatesPolling
+}
+
 // Validate проверяет корректность конфигурации
 func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
@@ -108,16 +471,189 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("write_timeout must be positive")
 	}
 
-	if c.GitLab.WebhookSecret == "" {
-		return fmt.Errorf("gitlab webhook secret is required")
+	if c.Server.IdleTimeout < 0 {
+		return fmt.Errorf("idle_timeout must not be negative")
+	}
+
+	if tls := c.Server.TLS; tls.Enabled() && (tls.CertFile == "" || tls.KeyFile == "") {
+		return fmt.Errorf("server.tls.cert_file and server.tls.key_file must be set together")
+	}
+
+	if _, err := c.Server.SocketMode(); err != nil {
+		return err
+	}
+
+	if err := c.GitLab.validate(); err != nil {
+		return err
 	}
 
 	if c.Telegram.BotToken == "" {
 		return fmt.Errorf("telegram bot token is required")
 	}
 
-	if len(c.Repositories) == 0 {
-		return fmt.Errorf("at least one repository must be configured")
+	if c.Telegram.APIURL == "" {
+		return fmt.Errorf("telegram.api_url is required")
+	}
+
+	if err := c.Telegram.Updates.validate(); err != nil {
+		return err
+	}
+
+	if c.Delivery.Workers <= 0 {
+		return fmt.Errorf("delivery.workers must be positive")
+	}
+
+	if c.Delivery.QueueSize <= 0 {
+		return fmt.Errorf("delivery.queue_size must be positive")
+	}
+
+	if err := c.Delivery.validate(c.Outbox.Enabled); err != nil {
+		return err
+	}
+
+	if c.Idempotency.TTL < 0 {
+		return fmt.Errorf("idempotency.ttl must not be negative")
+	}
+
+	if c.Idempotency.Enabled() && c.Idempotency.MaxEntries <= 0 {
+		return fmt.Errorf("idempotency.max_entries must be positive")
+	}
+
+	if c.Outbox.Enabled && c.Outbox.RetryInterval <= 0 {
+		return fmt.Errorf("outbox.retry_interval must be positive")
+	}
+
+	if c.Threads.Enabled && c.Threads.TTL <= 0 {
+		return fmt.Errorf("threads.ttl must be positive")
+	}
+
+	if c.Retention.Interval <= 0 {
+		return fmt.Errorf("retention.interval must be positive")
+	}
+
+	if c.Retention.Events < 0 || c.Retention.Outbox < 0 || c.Retention.DeadLetters < 0 {
+		return fmt.Errorf("retention periods must not be negative")
+	}
+
+	if c.UsesDatabase() && c.Storage.SQLitePath == "" {
+		return fmt.Errorf("storage.sqlite_path is required when registry.backend is %q or outbox, dead_letters, history, threads, chat_settings or telegram.updates polling is enabled", RegistrySQLite)
+	}
+
+	if err := c.Priorities.validate(); err != nil {
+		return fmt.Errorf("invalid priorities: %w", err)
+	}
+
+	if len(c.QuietHours.Chats) > 0 {
+		if c.QuietHours.StoragePath == "" {
+			return fmt.Errorf("quiet_hours.storage_path is required")
+		}
+		if c.QuietHours.CheckInterval <= 0 {
+			return fmt.Errorf("quiet_hours.check_interval must be positive")
+		}
+		if _, err := c.QuietHours.Schedules(); err != nil {
+			return fmt.Errorf("invalid quiet_hours: %w", err)
+		}
+	}
+
+	switch c.Registry.Backend {
+	case RegistryFile:
+		repositories := RepositoriesConfig{Repositories: c.Repositories, DefaultRoute: c.DefaultRoute}
+		if err := repositories.Validate(); err != nil {
+			return err
+		}
+		if err := c.GitLab.CheckRepositories(&repositories); err != nil {
+			return err
+		}
+	case RegistrySQLite:
+		// Записи проверяются при загрузке из базы
+	default:
+		return fmt.Errorf("invalid registry.backend %q: expected %q or %q", c.Registry.Backend, RegistryFile, RegistrySQLite)
+	}
+
+	return nil
+}
+
+func (c TelegramUpdatesConfig) validate() error {
+	switch c.Mode {
+	case UpdatesOff:
+	case UpdatesPolling:
+		// Таймаут передаётся в Telegram в целых секундах
+		if c.PollTimeout < time.Second {
+			return fmt.Errorf("telegram.updates.poll_timeout must be at least 1s")
+		}
+	case UpdatesWebhook:
+		return c.Webhook.validate()
+	default:
+		return fmt.Errorf("invalid telegram.updates.mode %q: expected %q, %q or %q", c.Mode, UpdatesOff, UpdatesPolling, UpdatesWebhook)
+	}
+	return nil
+}
+
+// webhookSecretPattern - допустимый секрет вебхука по требованиям Bot API
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+func (c TelegramWebhookConfig) validate() error {
+	if !webhookSecretPattern.MatchString(c.SecretToken.Value()) {
+		return fmt.Errorf("telegram.updates.webhook.secret_token is required: 1-256 characters A-Z, a-z, 0-9, _ and -")
+	}
+
+	if !c.Register {
+		return nil
+	}
+	// Telegram отправляет обновления только на HTTPS-адреса
+	u, err := url.Parse(c.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("telegram.updates.webhook.url must be an https URL, got %q", c.URL)
+	}
+	return nil
+}
+
+func (c DeliveryConfig) validate(outbox bool) error {
+	switch c.Backend {
+	case QueueMemory:
+		return nil
+	case QueueWAL:
+	default:
+		return fmt.Errorf("invalid delivery.backend %q: expected %q or %q", c.Backend, QueueMemory, QueueWAL)
+	}
+
+	// Outbox сам повторяет события, прерванные остановкой; вместе с журналом очереди
+	// такие уведомления после перезапуска пришли бы дважды
+	if outbox {
+		return fmt.Errorf("delivery.backend %q requires outbox.enabled: false", QueueWAL)
+	}
+	if c.WAL.Dir == "" {
+		return fmt.Errorf("delivery.wal.dir is required")
+	}
+	if c.WAL.SegmentSize <= 0 {
+		return fmt.Errorf("delivery.wal.segment_size must be positive")
+	}
+
+	switch c.WAL.Sync {
+	case SyncAlways, SyncNever:
+	case SyncInterval:
+		if c.WAL.SyncInterval <= 0 {
+			return fmt.Errorf("delivery.wal.sync_interval must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid delivery.wal.sync %q: expected %q, %q or %q", c.WAL.Sync, SyncAlways, SyncInterval, SyncNever)
+	}
+
+	return nil
+}
+
+func (c PriorityConfig) validate() error {
+	if _, err := c.DefaultPriority(); err != nil {
+		return err
+	}
+
+	for i, rule := range c.Rules {
+		if _, err := domain.ParsePriority(rule.Priority); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		if _, err := domain.CompileCondition(rule.Condition); err != nil {
+			return fmt.Errorf("rule %d: invalid condition %q: %w", i, rule.Condition, err)
+		}
 	}
 
 	return nil
@@ -158,44 +694,3 @@ func loadEnvFiles() error {
 	log.Printf("✓ Loaded env files: %v", existingFiles)
 	return nil
 }
-
-func loadRepositories(cfg *Config) error {
-	// Пути поиска repositories.json (в порядке приоритета)
-	paths := []string{
-		"config/repositories.json",
-		"repositories.json",
-		"/etc/tgGitlabBot/repositories.json",
-	}
-
-	var data []byte
-	var err error
-	var usedPath string
-
-	// Ищем файл в разных локациях
-	for _, path := range paths {
-		data, err = os.ReadFile(path)
-		if err == nil {
-			usedPath = path
-			break
-		}
-	}
-	if err != nil {
-		return fmt.Errorf("repositories.json not found in any location: %w", err)
-	}
-
-	log.Printf("Loading repositories from: %s", usedPath)
-
-	var repoConfig struct {
-		Repositories []domain.Repository `json:"repositories"`
-	}
-	if err := json.Unmarshal(data, &repoConfig); err != nil {
-		return fmt.Errorf("failed to parse repositories.json: %w", err)
-	}
-
-	cfg.Repositories = repoConfig.Repositories
-	if len(cfg.Repositories) == 0 {
-		return fmt.Errorf("repositories.json is empty")
-	}
-
-	return nil
-}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Dump возвращает итоговую конфигурацию в формате config.yaml со скрытыми секретами.
+// Рядом с каждым значением в комментарии указан его источник (файл, переменная окружения, умолчание).
+func (c *Config) Dump() ([]byte, error) {
+	root := toNode(reflect.ValueOf(*c), "", "mapstructure", c.Sources)
+
+	if c.RepositoriesPath != "" {
+		root.HeadComment = "repositories.json: " + c.RepositoriesPath
+	}
+	appendField(root, "repositories", toNode(reflect.ValueOf(c.Repositories), "", "json", nil))
+	if c.DefaultRoute != nil {
+		appendField(root, "default_route", toNode(reflect.ValueOf(c.DefaultRoute), "", "json", nil))
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// toNode строит YAML-узел по значению, сохраняя порядок полей структур.
+// Имена полей берутся из тега tag; поля без тега пропускаются. Типы с MarshalText
+// (в том числе domain.Secret) выводятся через него, поэтому секреты заменяются на ***.
+func toNode(v reflect.Value, key, tag string, sources map[string]string) *yaml.Node {
+	node := &yaml.Node{}
+
+	switch {
+	case v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface:
+		if v.IsNil() {
+			node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!null", "null"
+			break
+		}
+		return toNode(v.Elem(), key, tag, sources)
+
+	case v.Type() == durationType:
+		node.Kind, node.Value = yaml.ScalarNode, time.Duration(v.Int()).String()
+
+	case v.Type().Implements(textMarshalerType):
+		text, _ := v.Interface().(encoding.TextMarshaler).MarshalText()
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!str", string(text)
+
+	case v.Kind() == reflect.Struct:
+		node.Kind = yaml.MappingNode
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "" || name == "-" || !field.IsExported() {
+				continue
+			}
+			if strings.Contains(opts, "omitempty") && v.Field(i).IsZero() {
+				continue
+			}
+			appendField(node, name, toNode(v.Field(i), joinKey(key, name), tag, sources))
+		}
+		return node
+
+	case v.Kind() == reflect.Map:
+		node.Kind = yaml.MappingNode
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, fmt.Sprint(k.Interface()))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value := v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key()))
+			appendField(node, k, toNode(value, joinKey(key, k), tag, sources))
+		}
+		return node
+
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		node.Kind = yaml.SequenceNode
+		for i := range v.Len() {
+			// У элементов списка нет собственных источников: источник указан у самого списка
+			node.Content = append(node.Content, toNode(v.Index(i), "", tag, nil))
+		}
+		if v.Len() == 0 {
+			node.Style = yaml.FlowStyle
+		}
+
+	default:
+		if err := node.Encode(v.Interface()); err != nil {
+			node.Kind, node.Value = yaml.ScalarNode, fmt.Sprint(v.Interface())
+		}
+	}
+
+	if source, ok := sources[key]; ok {
+		node.LineComment = source
+	}
+	return node
+}
+
+func appendField(mapping *yaml.Node, name string, value *yaml.Node) {
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+
+	// Комментарий у сложного значения печатается после ключа, а не после первой строки содержимого
+	if value.Kind != yaml.ScalarNode && value.LineComment != "" {
+		keyNode.LineComment, value.LineComment = value.LineComment, ""
+	}
+
+	mapping.Content = append(mapping.Content, keyNode, value)
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + strings.ToLower(name)
+}
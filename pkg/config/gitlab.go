@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// instanceNamePattern - имя инстанса используется в пути вебхука /webhook/gitlab/<name>
+var instanceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// GitLabConfig - инстанс GitLab по умолчанию (webhook_secret, base_url) и дополнительные инстансы
+type GitLabConfig struct {
+	WebhookSecret domain.Secret `mapstructure:"webhook_secret"`
+	// BaseURL - адрес инстанса, сверяется с заголовком X-Gitlab-Instance
+	BaseURL string `mapstructure:"base_url"`
+	// Instances - дополнительные инстансы по имени; репозитории ссылаются на них полем instance
+	Instances map[string]GitLabInstanceConfig `mapstructure:"instances"`
+}
+
+type GitLabInstanceConfig struct {
+	BaseURL       string        `mapstructure:"base_url"`
+	WebhookSecret domain.Secret `mapstructure:"webhook_secret"`
+}
+
+// NamedGitLabInstance - инстанс GitLab с именем и ключом секрета в конфигурации
+type NamedGitLabInstance struct {
+	Name string
+	GitLabInstanceConfig
+	// SecretKey - ключ секрета в конфигурации, например gitlab.instances.internal.webhook_secret
+	SecretKey string
+}
+
+// AllInstances возвращает все инстансы GitLab: сначала инстанс по умолчанию (если задан его секрет),
+// затем дополнительные в алфавитном порядке
+func (c GitLabConfig) AllInstances() []NamedGitLabInstance {
+	instances := make([]NamedGitLabInstance, 0, len(c.Instances)+1)
+
+	if c.WebhookSecret != "" {
+		instances = append(instances, NamedGitLabInstance{
+			Name:                 domain.DefaultInstance,
+			GitLabInstanceConfig: GitLabInstanceConfig{BaseURL: c.BaseURL, WebhookSecret: c.WebhookSecret},
+			SecretKey:            "gitlab.webhook_secret",
+		})
+	}
+
+	names := make([]string, 0, len(c.Instances))
+	for name := range c.Instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		instances = append(instances, NamedGitLabInstance{
+			Name:                 name,
+			GitLabInstanceConfig: c.Instances[name],
+			SecretKey:            "gitlab.instances." + name + ".webhook_secret",
+		})
+	}
+
+	return instances
+}
+
+func (c GitLabConfig) validate() error {
+	if c.WebhookSecret == "" && len(c.Instances) == 0 {
+		return fmt.Errorf("gitlab webhook secret is required")
+	}
+
+	if _, reserved := c.Instances[domain.DefaultInstance]; reserved {
+		return fmt.Errorf("gitlab instance name %q is reserved for gitlab.webhook_secret", domain.DefaultInstance)
+	}
+
+	urls := make(map[string]string)
+
+	for _, instance := range c.AllInstances() {
+		if instance.Name != domain.DefaultInstance {
+			if !instanceNamePattern.MatchString(instance.Name) || instance.Name == "dry-run" {
+				return fmt.Errorf("invalid gitlab instance name %q: expected lowercase letters, digits, '-' or '_'", instance.Name)
+			}
+			if instance.WebhookSecret == "" {
+				return fmt.Errorf("%s is required", instance.SecretKey)
+			}
+		}
+
+		if instance.BaseURL == "" {
+			continue
+		}
+		u, err := url.Parse(instance.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("gitlab instance %q: base_url must be an absolute http(s) URL, got %q", instance.Name, instance.BaseURL)
+		}
+		if other, ok := urls[u.Host+u.Path]; ok {
+			return fmt.Errorf("gitlab instances %q and %q have the same base_url", other, instance.Name)
+		}
+		urls[u.Host+u.Path] = instance.Name
+	}
+
+	return nil
+}
+
+// CheckRepositories проверяет, что репозитории ссылаются только на настроенные инстансы GitLab
+func (c GitLabConfig) CheckRepositories(r *RepositoriesConfig) error {
+	known := make(map[string]struct{})
+	for _, instance := range c.AllInstances() {
+		known[instance.Name] = struct{}{}
+	}
+
+	var errs problems
+	for i, repo := range r.Repositories {
+		if _, ok := known[repo.Key().Instance]; !ok {
+			errs.add(fmt.Sprintf("$.repositories[%d].instance", i), "unknown gitlab instance %q", repo.Key().Instance)
+		}
+	}
+
+	return errs.err()
+}
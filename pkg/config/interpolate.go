@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// lookupFunc возвращает значение переменной окружения и признак её наличия
+type lookupFunc func(name string) (string, bool, error)
+
+// interpolateYAML заменяет ${VAR}, ${VAR:-default} и ${VAR:?error} во всех скалярных значениях YAML.
+// Ключи и комментарии не изменяются. Подстановка выполняется по дереву документа, а не по тексту,
+// поэтому значение переменной не может изменить структуру YAML.
+// Возвращает также переменные, значения которых попали в каждый ключ конфигурации (для лога источников).
+func interpolateYAML(data []byte, lookup lookupFunc) ([]byte, map[string][]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse yaml: %w", err)
+	}
+
+	var errs []error
+	vars := make(map[string][]string)
+
+	walkValues(&doc, "", func(key string, node *yaml.Node) {
+		if !strings.Contains(node.Value, "$") {
+			return
+		}
+
+		// Запоминаем переменные, которые действительно заданы: если сработало значение
+		// по умолчанию из ${VAR:-default}, источником остаётся файл
+		recording := func(name string) (string, bool, error) {
+			value, ok, err := lookup(name)
+			if ok && value != "" && !slices.Contains(vars[key], name) {
+				vars[key] = append(vars[key], name)
+			}
+			return value, ok, err
+		}
+
+		value, err := interpolate(node.Value, recording)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", node.Line, err))
+			return
+		}
+
+		if value != node.Value {
+			// Результат подстановки всегда строка: viper сам приведёт её к нужному типу поля
+			node.Value = value
+			node.Tag = "!!str"
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	})
+
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, vars, nil
+}
+
+// walkValues вызывает fn для каждого скалярного значения (но не ключа) документа.
+// key - ключ конфигурации в формате viper (a.b.c, в нижнем регистре); элементы списков
+// относятся к ключу самого списка.
+func walkValues(node *yaml.Node, key string, fn func(key string, node *yaml.Node)) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		fn(key, node)
+	case yaml.MappingNode:
+		// Содержимое маппинга - пары ключ, значение; ключи пропускаем
+		for i := 1; i < len(node.Content); i += 2 {
+			child := strings.ToLower(node.Content[i-1].Value)
+			if key != "" {
+				child = key + "." + child
+			}
+			walkValues(node.Content[i], child, fn)
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			walkValues(child, key, fn)
+		}
+	}
+}
+
+// interpolate подставляет переменные окружения в строку. Поддерживаемый синтаксис:
+//   - ${VAR}          - значение переменной или пустая строка
+//   - ${VAR:-default} - default, если переменная не задана или пуста
+//   - ${VAR-default}  - default, если переменная не задана
+//   - ${VAR:?message} - ошибка, если переменная не задана или пуста
+//   - ${VAR?message}  - ошибка, если переменная не задана
+//   - $$              - символ $
+//
+// Если переменная VAR не задана, но задана VAR_FILE, значением считается содержимое файла.
+func interpolate(s string, lookup lookupFunc) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte(s[i])
+			continue
+		}
+
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in %q", s)
+		}
+
+		value, err := expand(s[i+2:i+2+end], lookup)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		i += end + 2
+	}
+
+	return b.String(), nil
+}
+
+// expand вычисляет одно выражение из ${...}
+func expand(expr string, lookup lookupFunc) (string, error) {
+	name := expr
+	op, arg := "", ""
+
+	if idx := strings.IndexAny(expr, ":-?"); idx >= 0 {
+		name = expr[:idx]
+		rest := expr[idx:]
+		for _, candidate := range []string{":-", ":?", "-", "?"} {
+			if strings.HasPrefix(rest, candidate) {
+				op, arg = candidate, rest[len(candidate):]
+				break
+			}
+		}
+		if op == "" {
+			return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+		}
+	}
+
+	if !validVarName(name) {
+		return "", fmt.Errorf("invalid variable name %q in ${%s}", name, expr)
+	}
+
+	value, ok, err := lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch op {
+	case ":-":
+		if value == "" {
+			return arg, nil
+		}
+	case "-":
+		if !ok {
+			return arg, nil
+		}
+	case ":?":
+		if value == "" {
+			return "", missingVarError(name, arg)
+		}
+	case "?":
+		if !ok {
+			return "", missingVarError(name, arg)
+		}
+	}
+
+	return value, nil
+}
+
+func missingVarError(name, message string) error {
+	if message == "" {
+		return fmt.Errorf("required variable %s is not set", name)
+	}
+	return fmt.Errorf("required variable %s is not set: %s", name, message)
+}
+
+func validVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && (i == 0 || !isDigit) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// envLookup - окружение для тестов: EMPTY задана, но пуста; UNSET не задана
+func envLookup(name string) (string, bool, error) {
+	env := map[string]string{
+		"HOST":  "example.com",
+		"PORT":  "8080",
+		"EMPTY": "",
+		"A_1":   "a1",
+	}
+	value, ok := env[name]
+	return value, ok, nil
+}
+
+func TestInterpolate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"", ""},
+		{"${HOST}", "example.com"},
+		{"http://${HOST}:${PORT}/x", "http://example.com:8080/x"},
+		{"${A_1}", "a1"},
+		{"${UNSET}", ""},
+		{"${EMPTY}", ""},
+
+		// :- и - различаются для пустой переменной
+		{"${UNSET:-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${HOST:-fallback}", "example.com"},
+		{"${UNSET-fallback}", "fallback"},
+		{"${EMPTY-fallback}", ""},
+		{"${UNSET:-}", ""},
+		{"${UNSET:-a b:c-d?e}", "a b:c-d?e"},
+		{"${UNSET-VAR}", "VAR"},
+
+		// :? и ? не срабатывают для заданных переменных
+		{"${HOST:?required}", "example.com"},
+		{"${EMPTY?required}", ""},
+
+		// $ без { и экранирование $$
+		{"$$", "$"},
+		{"$${HOST}", "${HOST}"},
+		{"price: $5", "price: $5"},
+		{"$HOST", "$HOST"},
+		{"trailing $", "trailing $"},
+		{"$$$$", "$$"},
+		{"$$$", "$$"},
+	}
+
+	for _, tt := range tests {
+		got, err := interpolate(tt.in, envLookup)
+		if err != nil {
+			t.Errorf("interpolate(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("interpolate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{"${UNSET:?set it}", "required variable UNSET is not set: set it"},
+		{"${EMPTY:?set it}", "required variable EMPTY is not set: set it"},
+		{"${UNSET?}", "required variable UNSET is not set"},
+		{"${UNSET:?}", "required variable UNSET is not set"},
+		{"${HOST", `unterminated variable reference in "${HOST"`},
+		{"x ${HOST}${", "unterminated variable reference"},
+		{"${}", `invalid variable name "" in ${}`},
+		{"${1VAR}", `invalid variable name "1VAR"`},
+		{"${HOST.NAME}", `invalid variable name "HOST.NAME"`},
+		{"${:-x}", `invalid variable name ""`},
+		{"${HOST:x}", "invalid variable reference ${HOST:x}"},
+	}
+
+	for _, tt := range tests {
+		_, err := interpolate(tt.in, envLookup)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("interpolate(%q) error = %v, want %q", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestInterpolateLookupError(t *testing.T) {
+	failing := func(string) (string, bool, error) { return "", false, errors.New("read failed") }
+
+	if _, err := interpolate("${SECRET}", failing); err == nil || err.Error() != "read failed" {
+		t.Errorf("interpolate error = %v, want lookup error", err)
+	}
+}
+
+func TestInterpolateYAML(t *testing.T) {
+	src := `
+server:
+  host: ${HOST}
+  port: ${PORT}
+  name: plain
+  # ${UNSET:?комментарии не подставляются}
+${HOST}: key is not interpolated
+hooks:
+  - ${HOST:-a}
+  - ${UNSET:-b}
+price: $$5
+`
+	out, vars, err := interpolateYAML([]byte(src), envLookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := yaml.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"server": map[string]any{
+			"host": "example.com",
+			// Подставленное значение всегда строка: тип поля приводит viper
+			"port": "8080",
+			"name": "plain",
+		},
+		"${HOST}": "key is not interpolated",
+		"hooks":   []any{"example.com", "b"},
+		"price":   "$5",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("interpolateYAML =\n%v\nwant\n%v", got, want)
+	}
+
+	// Источник - только действительно заданные переменные
+	wantVars := map[string][]string{
+		"server.host": {"HOST"},
+		"server.port": {"PORT"},
+		"hooks":       {"HOST"},
+	}
+	if !reflect.DeepEqual(vars, wantVars) {
+		t.Errorf("vars = %v, want %v", vars, wantVars)
+	}
+}
+
+func TestInterpolateYAMLKeepsStructure(t *testing.T) {
+	// Значение переменной не может добавить ключи или изменить тип узла
+	inject := func(name string) (string, bool, error) {
+		return "x\nadmin:\n  token: stolen", true, nil
+	}
+
+	out, _, err := interpolateYAML([]byte("name: ${VALUE}\n"), inject)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := yaml.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["name"] != "x\nadmin:\n  token: stolen" {
+		t.Errorf("interpolateYAML = %v, want a single string value", got)
+	}
+}
+
+func TestInterpolateYAMLErrors(t *testing.T) {
+	src := "a: ${UNSET:?first}\nb: ok\nc: ${BROKEN\n"
+
+	_, _, err := interpolateYAML([]byte(src), envLookup)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Сообщаются все ошибки с номерами строк
+	for _, want := range []string{"line 1: required variable UNSET is not set: first", "line 3: unterminated variable reference"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+
+	if _, _, err := interpolateYAML([]byte("a: [unclosed"), envLookup); err == nil || !strings.Contains(err.Error(), "failed to parse yaml") {
+		t.Errorf("invalid yaml error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+)
+
+// Options - параметры загрузки конфигурации из командной строки
+type Options struct {
+	// ConfigFile - явный путь к config.yaml; если задан, поиск по стандартным путям не выполняется
+	ConfigFile string
+	// RepositoriesFile - явный путь к repositories.json
+	RepositoriesFile string
+	// EnvPrefix - префикс переменных окружения, переопределяющих ключи конфигурации
+	// (TGBOT -> TGBOT_SERVER_PORT для server.port); пусто - без префикса
+	EnvPrefix string
+}
+
+// RegisterFlags регистрирует флаги --config, --repositories и --env-prefix.
+// Значения по умолчанию берутся из TGBOT_CONFIG, TGBOT_REPOSITORIES и TGBOT_ENV_PREFIX.
+func (o *Options) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.ConfigFile, "config", os.Getenv("TGBOT_CONFIG"),
+		"путь к config.yaml (по умолчанию объединяются найденные в /etc/tgGitlabBot, ~/.tgGitlabBot, ., ./config)")
+	fs.StringVar(&o.RepositoriesFile, "repositories", os.Getenv("TGBOT_REPOSITORIES"),
+		"путь к repositories.json (по умолчанию ищется рядом с config.yaml и в стандартных путях)")
+	fs.StringVar(&o.EnvPrefix, "env-prefix", os.Getenv("TGBOT_ENV_PREFIX"),
+		"префикс переменных окружения для ключей конфигурации, например TGBOT")
+}
+
+// configSearchDirs - директории поиска config.yaml от низшего приоритета к высшему:
+// найденные файлы объединяются, и значения из более поздних перекрывают ранние
+func configSearchDirs() []string {
+	dirs := []string{"/etc/tgGitlabBot"}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".tgGitlabBot"))
+	}
+	return append(dirs, ".", "./config")
+}
+
+// configFiles возвращает слои конфигурации в порядке применения
+func (o Options) configFiles() ([]string, error) {
+	if o.ConfigFile != "" {
+		if _, err := os.Stat(o.ConfigFile); err != nil {
+			return nil, err
+		}
+		return []string{o.ConfigFile}, nil
+	}
+
+	var files []string
+	for _, dir := range configSearchDirs() {
+		for _, name := range []string{"config.yaml", "config.yml"} {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				files = append(files, path)
+				break
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return files, nil
+}
+
+// repositoriesFiles возвращает пути поиска repositories.json в порядке приоритета
+func (o Options) repositoriesFiles(configFiles []string) []string {
+	if o.RepositoriesFile != "" {
+		return []string{o.RepositoriesFile}
+	}
+
+	var paths []string
+	// Сначала рядом с самым приоритетным config.yaml
+	if len(configFiles) > 0 {
+		paths = append(paths, filepath.Join(filepath.Dir(configFiles[len(configFiles)-1]), "repositories.json"))
+	}
+	paths = append(paths, "config/repositories.json", "repositories.json")
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".tgGitlabBot", "repositories.json"))
+	}
+	paths = append(paths, "/etc/tgGitlabBot/repositories.json")
+
+	return uniquePaths(paths)
+}
+
+func uniquePaths(paths []string) []string {
+	seen := make(map[string]struct{}, len(paths))
+	unique := paths[:0]
+	for _, path := range paths {
+		clean := filepath.Clean(path)
+		if _, ok := seen[clean]; ok {
+			continue
+		}
+		seen[clean] = struct{}{}
+		unique = append(unique, path)
+	}
+	return unique
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// RepositoriesConfig - содержимое repositories.json
+type RepositoriesConfig struct {
+	Repositories []domain.Repository `json:"repositories"`
+	DefaultRoute *domain.Repository  `json:"default_route"`
+}
+
+// Set возвращает конфигурацию в виде набора доменных записей
+func (r *RepositoriesConfig) Set() domain.RepositorySet {
+	return domain.RepositorySet{Repositories: r.Repositories, DefaultRoute: r.DefaultRoute}
+}
+
+// LoadRepositoriesFile читает и строго проверяет файл репозиториев
+func LoadRepositoriesFile(path string) (*RepositoriesConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return DecodeRepositories(data)
+}
+
+// loadRepositories загружает первый найденный repositories.json из paths.
+// Если optional и файл не найден, конфигурация репозиториев остаётся пустой.
+func loadRepositories(cfg *Config, paths []string, optional bool) error {
+	var data []byte
+	var err error
+	var usedPath string
+
+	// Ищем файл в разных локациях
+	for _, path := range paths {
+		data, err = os.ReadFile(path)
+		if err == nil {
+			usedPath = path
+			break
+		}
+	}
+	if err != nil && optional {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repositories.json not found in any of %v: %w", paths, err)
+	}
+
+	log.Printf("Loading repositories from: %s", usedPath)
+
+	repoConfig, err := DecodeRepositories(data)
+	if err != nil {
+		return err
+	}
+
+	cfg.Repositories = repoConfig.Repositories
+	cfg.DefaultRoute = repoConfig.DefaultRoute
+	cfg.RepositoriesPath = usedPath
+
+	return nil
+}
@@ -0,0 +1,357 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+	"github.com/sensetion/tgGitlabBot/pkg/glob"
+)
+
+// Problem - одна ошибка в конфигурации репозиториев с JSON-путём до места ошибки
+type Problem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// RepositoriesError содержит все найденные в конфигурации репозиториев ошибки
+type RepositoriesError struct {
+	Problems []Problem
+}
+
+func (e *RepositoriesError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("%d problem(s) in repositories config:", len(e.Problems)))
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+type problems []Problem
+
+func (p *problems) add(path, format string, args ...any) {
+	*p = append(*p, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return &RepositoriesError{Problems: p}
+}
+
+var (
+	// Telegram chat_id: числовой ID (у групп и каналов отрицательный) или @username канала
+	chatIDPattern = regexp.MustCompile(`^(-?[1-9][0-9]{0,19}|@[A-Za-z][A-Za-z0-9_]{4,31})$`)
+	// ID проекта GitLab - положительное целое число
+	projectIDPattern = regexp.MustCompile(`^[1-9][0-9]*$`)
+
+	topLevelFields   = []string{"repositories", "default_route"}
+	repositoryFields = jsonFieldNames(reflect.TypeOf(domain.Repository{}))
+	// Маршрут по умолчанию обслуживает неизвестные проекты любого инстанса, для них действует секрет инстанса
+	defaultRouteField = without(repositoryFields, "id", "instance", "webhook_secret", "webhook_secrets")
+)
+
+// DecodeRepositories строго разбирает repositories.json: неизвестные ключи, неверные типы
+// и семантические ошибки собираются вместе и возвращаются одной ошибкой *RepositoriesError
+func DecodeRepositories(data []byte) (*RepositoriesConfig, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, &RepositoriesError{Problems: []Problem{{Path: "$", Message: describeJSONError(data, err)}}}
+	}
+
+	var (
+		cfg  RepositoriesConfig
+		errs problems
+	)
+
+	checkUnknownFields("$", raw, topLevelFields, &errs)
+
+	if value, ok := raw["repositories"]; ok {
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			errs.add("$.repositories", "must be an array")
+		}
+		for i, item := range items {
+			path := fmt.Sprintf("$.repositories[%d]", i)
+			cfg.Repositories = append(cfg.Repositories, decodeRepository(path, item, repositoryFields, &errs))
+		}
+	}
+
+	if value, ok := raw["default_route"]; ok && string(bytes.TrimSpace(value)) != "null" {
+		route := decodeRepository("$.default_route", value, defaultRouteField, &errs)
+		cfg.DefaultRoute = &route
+	}
+
+	errs = append(errs, cfg.problems(false)...)
+
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// DecodeRepository строго разбирает одну запись репозитория (тело запроса административного API)
+func DecodeRepository(data []byte) (domain.Repository, error) {
+	var errs problems
+	repo := decodeRepository("$", data, repositoryFields, &errs)
+	return repo, errs.err()
+}
+
+// DecodeDefaultRoute строго разбирает маршрут по умолчанию
+func DecodeDefaultRoute(data []byte) (domain.Repository, error) {
+	var errs problems
+	route := decodeRepository("$", data, defaultRouteField, &errs)
+	return route, errs.err()
+}
+
+// Validate проверяет корректность списка репозиториев и маршрута по умолчанию.
+// Возвращает *RepositoriesError со всеми найденными ошибками.
+func (r *RepositoriesConfig) Validate() error {
+	return r.problems(false).err()
+}
+
+// ValidateEntries проверяет записи, как Validate, но допускает пустой список:
+// реестр в базе данных заполняется через административное API
+func (r *RepositoriesConfig) ValidateEntries() error {
+	return r.problems(true).err()
+}
+
+func (r *RepositoriesConfig) problems(allowEmpty bool) problems {
+	var errs problems
+
+	if len(r.Repositories) == 0 && !allowEmpty {
+		errs.add("$.repositories", "at least one repository must be configured")
+	}
+
+	// Один проект может отправлять уведомления в несколько чатов,
+	// но тройка (instance, id, telegram_channel_id) должна быть уникальной
+	seen := make(map[[3]string]int)
+
+	for i, repo := range r.Repositories {
+		path := fmt.Sprintf("$.repositories[%d]", i)
+
+		switch {
+		case repo.ID == "":
+			errs.add(path+".id", "is required")
+		case !projectIDPattern.MatchString(repo.ID):
+			errs.add(path+".id", "must be a numeric GitLab project ID, got %q", repo.ID)
+		}
+
+		if repo.Instance != "" && !instanceNamePattern.MatchString(repo.Instance) {
+			errs.add(path+".instance", "invalid gitlab instance name %q", repo.Instance)
+		}
+
+		validateRoute(path, &repo, &errs)
+		validateWebhookSecrets(path, &repo, &errs)
+
+		if repo.ID == "" || repo.TelegramChatID == "" {
+			continue
+		}
+		key := [3]string{repo.Key().Instance, repo.ID, repo.TelegramChatID}
+		if first, ok := seen[key]; ok {
+			errs.add(path, "duplicate of $.repositories[%d] (same instance %q, id %q and telegram_channel_id %q)", first, key[0], repo.ID, repo.TelegramChatID)
+			continue
+		}
+		seen[key] = i
+	}
+
+	if route := r.DefaultRoute; route != nil {
+		if route.TelegramChatID == "" {
+			errs.add("$.default_route.telegram_channel_id", "is required")
+		}
+		validateRoute("$.default_route", route, &errs)
+	}
+
+	return errs
+}
+
+// validateRoute проверяет поля маршрута, общие для репозитория и маршрута по умолчанию
+func validateRoute(path string, repo *domain.Repository, errs *problems) {
+	switch {
+	case repo.TelegramChatID == "" && repo.IsEnabled():
+		errs.add(path+".telegram_channel_id", "is required for an enabled repository")
+	case repo.TelegramChatID != "" && !chatIDPattern.MatchString(repo.TelegramChatID):
+		errs.add(path+".telegram_channel_id", "must be a numeric chat ID or @channel_username, got %q", repo.TelegramChatID)
+	}
+
+	for i, branch := range repo.Branches {
+		if strings.TrimSpace(branch) == "" {
+			errs.add(fmt.Sprintf("%s.branches[%d]", path, i), "must not be empty")
+		}
+	}
+
+	for i, pattern := range repo.Paths {
+		if pattern == "" || !glob.Valid(pattern) {
+			errs.add(fmt.Sprintf("%s.paths[%d]", path, i), "invalid glob pattern %q", pattern)
+		}
+	}
+
+	if repo.Condition != "" {
+		if _, err := domain.CompileCondition(repo.Condition); err != nil {
+			errs.add(path+".condition", "invalid expression %q: %v", repo.Condition, err)
+		}
+	}
+}
+
+// validateWebhookSecrets проверяет собственные секреты вебхука репозитория
+func validateWebhookSecrets(path string, repo *domain.Repository, errs *problems) {
+	if repo.WebhookSecret != "" && strings.TrimSpace(repo.WebhookSecret.Value()) != repo.WebhookSecret.Value() {
+		errs.add(path+".webhook_secret", "must not have leading or trailing spaces")
+	}
+
+	for i, secret := range repo.WebhookSecrets {
+		if secret.Secret == "" {
+			errs.add(fmt.Sprintf("%s.webhook_secrets[%d].secret", path, i), "is required")
+		}
+	}
+}
+
+// decodeRepository разбирает объект репозитория по полям, чтобы сообщить о каждой ошибке типа отдельно
+func decodeRepository(path string, data json.RawMessage, allowed []string, errs *problems) domain.Repository {
+	var repo domain.Repository
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		errs.add(path, "must be an object")
+		return repo
+	}
+
+	checkUnknownFields(path, fields, allowed, errs)
+
+	value := reflect.ValueOf(&repo).Elem()
+	for i := range value.NumField() {
+		name := jsonName(value.Type().Field(i))
+		raw, ok := fields[name]
+		if !ok || !slices.Contains(allowed, name) {
+			continue
+		}
+
+		if err := json.Unmarshal(raw, value.Field(i).Addr().Interface()); err != nil {
+			errs.add(path+"."+name, "%s", describeTypeError(err))
+		}
+	}
+
+	return repo
+}
+
+func checkUnknownFields(path string, fields map[string]json.RawMessage, allowed []string, errs *problems) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if slices.Contains(allowed, key) {
+			continue
+		}
+		if suggestion := closest(key, allowed); suggestion != "" {
+			errs.add(path+"."+key, "unknown field (did you mean %q?)", suggestion)
+			continue
+		}
+		errs.add(path+"."+key, "unknown field")
+	}
+}
+
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := lineColumn(data, syntaxErr.Offset)
+		return fmt.Sprintf("invalid JSON at line %d, column %d: %v", line, col, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return "must be an object"
+	}
+	return err.Error()
+}
+
+func describeTypeError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)
+	}
+	return err.Error()
+}
+
+func lineColumn(data []byte, offset int64) (int, int) {
+	line, col := 1, 1
+	for i := int64(0); i < offset && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			col = 1
+			continue
+		}
+		col++
+	}
+	return line, col
+}
+
+// closest возвращает наиболее похожее допустимое имя поля (для подсказок при опечатках)
+func closest(key string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := levenshtein(key, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+func jsonFieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		if name := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func without(list []string, values ...string) []string {
+	return slices.DeleteFunc(slices.Clone(list), func(item string) bool { return slices.Contains(values, item) })
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/sensetion/tgGitlabBot/internal/domain"
+)
+
+// fileSuffix - суффикс переменной окружения, указывающей на файл со значением (Docker/Kubernetes secrets)
+const fileSuffix = "_FILE"
+
+// readSecretFile читает значение из файла, отбрасывая завершающие переводы строк
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// lookupEnvOrFile возвращает значение переменной NAME, а если она не задана - содержимое файла из NAME_FILE
+func lookupEnvOrFile(name string) (string, bool, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+
+	path, ok := os.LookupEnv(name + fileSuffix)
+	if !ok {
+		return "", false, nil
+	}
+
+	value, err := readSecretFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s%s=%s: %w", name, fileSuffix, path, err)
+	}
+	return value, true, nil
+}
+
+// applyFileOverrides для каждого ключа конфигурации проверяет переменную [PREFIX_]<KEY>_FILE
+// (например, TELEGRAM_BOT_TOKEN_FILE для telegram.bot_token) и подставляет содержимое файла.
+// Возвращает соответствие ключ -> путь к файлу для последующего отслеживания ротации.
+func applyFileOverrides(v *viper.Viper, envPrefix string) (map[string]string, error) {
+	files := make(map[string]string)
+
+	for _, key := range v.AllKeys() {
+		envName := envName(envPrefix, key) + fileSuffix
+
+		path, ok := os.LookupEnv(envName)
+		if !ok || path == "" {
+			continue
+		}
+
+		value, err := readSecretFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%s: %w", envName, path, err)
+		}
+
+		v.Set(key, value)
+		files[key] = path
+		log.Printf("🔐 %s загружен из файла %s", key, path)
+	}
+
+	return files, nil
+}
+
+// RotatingSecret хранит актуальное значение секрета. Если секрет загружен из файла,
+// Watch периодически перечитывает файл, и новое значение применяется без перезапуска.
+type RotatingSecret struct {
+	name  string
+	path  string
+	value atomic.Pointer[string]
+}
+
+// NewRotatingSecret создаёт секрет с начальным значением; path может быть пустым
+func NewRotatingSecret(name string, value domain.Secret, path string) *RotatingSecret {
+	s := &RotatingSecret{name: name, path: path}
+	plain := value.Value()
+	s.value.Store(&plain)
+	return s
+}
+
+// Value возвращает текущее значение секрета
+func (s *RotatingSecret) Value() string {
+	return *s.value.Load()
+}
+
+// Watch перечитывает файл секрета с интервалом interval до отмены контекста.
+// Ничего не делает, если секрет не из файла или interval не положительный.
+func (s *RotatingSecret) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			value, err := readSecretFile(s.path)
+			if err != nil {
+				log.Printf("⚠️ Не удалось перечитать %s из %s, используется прежнее значение: %v", s.name, s.path, err)
+				continue
+			}
+			if value == "" || value == s.Value() {
+				continue
+			}
+			s.value.Store(&value)
+			log.Printf("🔐 %s обновлён из файла %s", s.name, s.path)
+		}
+	}
+}
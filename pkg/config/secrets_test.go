@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyFileOverridesWithoutKeyInConfig(t *testing.T) {
+	token := writeFile(t, "token", "secret-token\n")
+	t.Setenv("TGBOT_TELEGRAM_BOT_TOKEN_FILE", token)
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	setDefaults(v)
+	// Файл конфигурации не содержит telegram.bot_token
+	s := newSources("TGBOT")
+	if err := s.mergeLayer(v, writeFile(t, "config.yaml", "log_level: debug\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := applyFileOverrides(v, "TGBOT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.GetString("telegram.bot_token"); got != "secret-token" {
+		t.Errorf("telegram.bot_token = %q, want value from file", got)
+	}
+	if files["telegram.bot_token"] != token {
+		t.Errorf("secret files = %v, want telegram.bot_token -> %s", files, token)
+	}
+}
+
+func TestMergeLayerTracksInterpolatedSecretFiles(t *testing.T) {
+	token := writeFile(t, "token", "secret-token")
+	secret := writeFile(t, "secret", "webhook-secret")
+	t.Setenv("TELEGRAM_BOT_TOKEN_FILE", token)
+	t.Setenv("GITLAB_WEBHOOK_SECRET_FILE", secret)
+
+	src := "telegram:\n  bot_token: ${TELEGRAM_BOT_TOKEN}\ngitlab:\n  webhook_secret: prefix-${GITLAB_WEBHOOK_SECRET}\n"
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	// С префиксом имя переменной в ${VAR} не совпадает с TGBOT_<KEY>_FILE
+	s := newSources("TGBOT")
+	if err := s.mergeLayer(v, writeFile(t, "config.yaml", src)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := v.GetString("telegram.bot_token"); got != "secret-token" {
+		t.Errorf("telegram.bot_token = %q, want value from file", got)
+	}
+	if got := s.secretFiles["telegram.bot_token"]; got != (secretFile{env: "TELEGRAM_BOT_TOKEN_FILE", path: token}) {
+		t.Errorf("telegram.bot_token secret file = %+v, want %s", got, token)
+	}
+	// Файл составляет только часть значения - при ротации его нельзя подставить целиком
+	if got, ok := s.secretFiles["gitlab.webhook_secret"]; ok {
+		t.Errorf("gitlab.webhook_secret must not be tracked, got %+v", got)
+	}
+
+	// Ключ, переопределённый следующим слоем, больше не берётся из файла
+	if err := s.mergeLayer(v, writeFile(t, "override.yaml", "telegram:\n  bot_token: plain\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.secretFiles["telegram.bot_token"]; ok {
+		t.Errorf("overridden telegram.bot_token must not be tracked, got %+v", got)
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// sources запоминает, откуда взято каждое значение конфигурации: на дежурстве
+// часто нужно понять, какое из значений (файл, переменная окружения, умолчание) победило
+type sources struct {
+	envPrefix string
+	// files - ключ -> файл слоя, задавшего значение последним
+	files map[string]string
+	// vars - ключ -> переменные окружения, подставленные в значение через ${VAR}
+	vars map[string][]string
+	// secretFiles - ключ -> файл, из которого значение целиком подставлено через ${VAR} при заданной VAR_FILE
+	secretFiles map[string]secretFile
+}
+
+// secretFile - файл секрета и переменная окружения, указавшая на него
+type secretFile struct {
+	env  string
+	path string
+}
+
+func newSources(envPrefix string) *sources {
+	return &sources{
+		envPrefix: envPrefix,
+		files:     make(map[string]string),
+		vars:      make(map[string][]string),
+
+		secretFiles: make(map[string]secretFile),
+	}
+}
+
+// mergeLayer читает файл конфигурации, подставляет переменные окружения и накладывает результат на v
+func (s *sources) mergeLayer(v *viper.Viper, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Подставляем ${VAR}, ${VAR:-default}, ${VAR:?error} из окружения до анмаршалинга
+	// Запоминаем переменные, значения которых прочитаны из VAR_FILE, чтобы отслеживать ротацию файла
+	fromFiles := make(map[string]string)
+	lookup := func(name string) (string, bool, error) {
+		value, ok, err := lookupEnvOrFile(name)
+		if _, set := os.LookupEnv(name); ok && !set {
+			fromFiles[name] = value
+		}
+		return value, ok, err
+	}
+
+	out, vars, err := interpolateYAML(data, lookup)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	layer := viper.New()
+	layer.SetConfigType("yaml")
+	if err := layer.ReadConfig(bytes.NewReader(out)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, key := range layer.AllKeys() {
+		s.files[key] = path
+		delete(s.vars, key)
+		delete(s.secretFiles, key)
+	}
+	for key, names := range vars {
+		s.vars[key] = names
+
+		// Ротация подменяет значение целиком, поэтому отслеживаются только значения вида ${VAR}
+		if value, ok := fromFiles[names[0]]; ok && len(names) == 1 && layer.GetString(key) == value {
+			env := names[0] + fileSuffix
+			s.secretFiles[key] = secretFile{env: env, path: os.Getenv(env)}
+		}
+	}
+
+	if err := v.MergeConfig(bytes.NewReader(out)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// resolve возвращает источник каждого ключа итоговой конфигурации
+func (s *sources) resolve(v *viper.Viper, secretFiles map[string]string) map[string]string {
+	result := make(map[string]string)
+
+	for _, key := range v.AllKeys() {
+		env := envName(s.envPrefix, key)
+
+		switch path, fromFile := secretFiles[key]; {
+		case fromFile && s.secretFiles[key].path == path:
+			result[key] = fmt.Sprintf("file %s (%s)", path, s.secretFiles[key].env)
+		case fromFile:
+			result[key] = fmt.Sprintf("file %s (%s%s)", path, env, fileSuffix)
+		case os.Getenv(env) != "":
+			result[key] = "env " + env
+		case len(s.vars[key]) > 0:
+			result[key] = fmt.Sprintf("env %s via %s", strings.Join(s.vars[key], ", "), s.files[key])
+		case s.files[key] != "":
+			result[key] = "file " + s.files[key]
+		default:
+			result[key] = "default"
+		}
+	}
+
+	return result
+}
+
+// envName возвращает имя переменной окружения, переопределяющей ключ: server.port -> [PREFIX_]SERVER_PORT
+func envName(prefix, key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if prefix != "" {
+		name = strings.ToUpper(prefix) + "_" + name
+	}
+	return name
+}
+
+// logSources выводит источник каждого значения конфигурации (без самих значений - среди них есть секреты)
+func logSources(sources map[string]string) {
+	keys := make([]string, 0, len(sources))
+	width := 0
+	for key := range sources {
+		keys = append(keys, key)
+		width = max(width, len(key))
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("🧭 Источники значений конфигурации:")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n  %-*s  %s", width, key, sources[key])
+	}
+	log.Print(b.String())
+}
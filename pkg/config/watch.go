@@ -0,0 +1,138 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/sensetion/tgGitlabBot/pkg/metrics"
+)
+
+// reloadDebounce - пауза после последнего события файловой системы перед перечитыванием:
+// редакторы и kubectl обновляют файл несколькими операциями подряд
+const reloadDebounce = 300 * time.Millisecond
+
+var repositoriesReloads = metrics.NewCounterVec(
+	"tgbot_repositories_reloads_total",
+	"repositories.json reloads by result",
+	"result",
+)
+
+// RepositoriesWatcher перечитывает repositories.json при изменении файла или по запросу (SIGHUP).
+// Новая конфигурация передаётся в apply только после успешной проверки; при ошибке
+// остаётся последняя корректная конфигурация.
+type RepositoriesWatcher struct {
+	path    string
+	apply   func(*RepositoriesConfig) error
+	trigger chan struct{}
+	last    []byte
+}
+
+func NewRepositoriesWatcher(path string, apply func(*RepositoriesConfig) error) *RepositoriesWatcher {
+	last, _ := os.ReadFile(path)
+
+	return &RepositoriesWatcher{
+		path:    path,
+		apply:   apply,
+		trigger: make(chan struct{}, 1),
+		last:    last,
+	}
+}
+
+// Reload запрашивает перечитывание файла, не дожидаясь результата
+func (w *RepositoriesWatcher) Reload() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run следит за файлом до отмены контекста
+func (w *RepositoriesWatcher) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Следим за директорией, а не за файлом: при атомарной замене (rename) и обновлении
+	// ConfigMap в Kubernetes (симлинк ..data) исходный inode файла исчезает
+	dir := filepath.Dir(w.path)
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	log.Printf("👀 Отслеживаются изменения %s", w.path)
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	name := filepath.Base(w.path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			base := filepath.Base(event.Name)
+			if base == name || base == "..data" {
+				debounce.Reset(reloadDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("⚠️ Ошибка отслеживания %s: %v", w.path, err)
+
+		case <-debounce.C:
+			w.reload(false)
+
+		case <-w.trigger:
+			w.reload(true)
+		}
+	}
+}
+
+// reload перечитывает файл. Если содержимое не изменилось, а перезагрузка не запрошена явно,
+// ничего не происходит.
+func (w *RepositoriesWatcher) reload(force bool) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.fail(fmt.Errorf("failed to read %s: %w", w.path, err))
+		return
+	}
+
+	if !force && bytes.Equal(data, w.last) {
+		return
+	}
+
+	repositories, err := DecodeRepositories(data)
+	if err == nil {
+		err = w.apply(repositories)
+	}
+	if err != nil {
+		w.fail(err)
+		return
+	}
+
+	w.last = data
+	repositoriesReloads.Inc("success")
+	log.Printf("🔄 %s перезагружен: %d репозиториев", w.path, len(repositories.Repositories))
+}
+
+func (w *RepositoriesWatcher) fail(err error) {
+	repositoriesReloads.Inc("failure")
+	log.Printf("❌ Перезагрузка %s отклонена, используется предыдущая конфигурация: %v", w.path, err)
+}
@@ -0,0 +1,244 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+func eval(n node, env map[string]any) (any, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *listNode:
+		items := make([]any, 0, len(n.items))
+		for _, item := range n.items {
+			v, err := eval(item, env)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+
+	case *identNode:
+		return resolve(n, env)
+
+	case *unaryNode:
+		v, err := evalBool(n.operand, env)
+		if err != nil {
+			return nil, err
+		}
+		return !v, nil
+
+	case *binaryNode:
+		return evalBinary(n, env)
+	}
+
+	return nil, &Error{Pos: n.position(), Msg: "unknown expression node"}
+}
+
+func evalBool(n node, env map[string]any) (bool, error) {
+	v, err := eval(n, env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, &Error{Pos: n.position(), Msg: "expected bool, got " + typeName(v)}
+	}
+	return b, nil
+}
+
+func evalBinary(n *binaryNode, env map[string]any) (any, error) {
+	// Логические операторы вычисляются по короткой схеме
+	switch n.op {
+	case tokAnd, tokOr:
+		left, err := evalBool(n.left, env)
+		if err != nil {
+			return nil, err
+		}
+		if (n.op == tokAnd && !left) || (n.op == tokOr && left) {
+			return left, nil
+		}
+		return evalBool(n.right, env)
+	}
+
+	left, err := eval(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == tokMatches {
+		s, ok := left.(string)
+		if !ok {
+			return nil, &Error{Pos: n.left.position(), Msg: "left operand of 'matches' must be string, got " + typeName(left)}
+		}
+		return n.re.MatchString(s), nil
+	}
+
+	right, err := eval(n.right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case tokEq:
+		return equal(left, right), nil
+	case tokNeq:
+		return !equal(left, right), nil
+	case tokIn:
+		return contains(n, left, right)
+	}
+
+	return compare(n, left, right)
+}
+
+// resolve находит значение поля в окружении по пути a.b.c
+func resolve(n *identNode, env map[string]any) (any, error) {
+	var cur any = env
+
+	for i, key := range n.path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, &Error{Pos: n.pos, Msg: fmt.Sprintf("%s is not an object", strings.Join(n.path[:i], "."))}
+		}
+
+		cur, ok = m[key]
+		if !ok {
+			return nil, &Error{Pos: n.pos, Msg: "unknown field " + strings.Join(n.path[:i+1], ".")}
+		}
+	}
+
+	return normalize(cur), nil
+}
+
+// normalize приводит значения окружения к типам языка: string, float64, bool, []any
+func normalize(v any) any {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		items := make([]any, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	}
+	return v
+}
+
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case string:
+		s, ok := b.(string)
+		return ok && a == s
+	case float64:
+		f, ok := b.(float64)
+		return ok && a == f
+	case bool:
+		v, ok := b.(bool)
+		return ok && a == v
+	case []any:
+		list, ok := b.([]any)
+		if !ok || len(a) != len(list) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], list[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func contains(n *binaryNode, item, collection any) (any, error) {
+	switch c := collection.(type) {
+	case []any:
+		for _, v := range c {
+			if equal(item, v) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return nil, &Error{Pos: n.left.position(), Msg: "left operand of 'in' must be string when searching in a string, got " + typeName(item)}
+		}
+		return strings.Contains(c, s), nil
+	}
+
+	return nil, &Error{Pos: n.right.position(), Msg: "right operand of 'in' must be list or string, got " + typeName(collection)}
+}
+
+func compare(n *binaryNode, left, right any) (any, error) {
+	var cmp int
+
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, mismatch(n, left, right)
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, mismatch(n, left, right)
+		}
+		cmp = strings.Compare(l, r)
+
+	default:
+		return nil, &Error{Pos: n.pos, Msg: "operator " + n.op.String() + " is not defined for " + typeName(left)}
+	}
+
+	switch n.op {
+	case tokLt:
+		return cmp < 0, nil
+	case tokLte:
+		return cmp <= 0, nil
+	case tokGt:
+		return cmp > 0, nil
+	case tokGte:
+		return cmp >= 0, nil
+	}
+
+	return nil, &Error{Pos: n.pos, Msg: "unknown operator " + n.op.String()}
+}
+
+func mismatch(n *binaryNode, left, right any) error {
+	return &Error{Pos: n.pos, Msg: fmt.Sprintf("cannot compare %s with %s", typeName(left), typeName(right))}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
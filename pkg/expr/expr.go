@@ -0,0 +1,101 @@
+// Package expr реализует небольшой язык логических выражений для правил маршрутизации.
+//
+// Выражение вычисляется в песочнице: нет вызовов функций, циклов и доступа к чему-либо,
+// кроме переданного окружения. Поддерживаются:
+//   - литералы: "строки", 'строки', числа, true, false, списки [a, b]
+//   - поля окружения через точку: event.kind, event.project
+//   - сравнения: ==, !=, <, <=, >, >=
+//   - проверка вхождения: x in [..], "подстрока" in строка, "файл" in список
+//   - регулярные выражения (RE2): event.ref matches "^release/"
+//   - логика: &&, ||, ! (а также and, or, not)
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// maxSourceLength ограничивает длину выражения
+const maxSourceLength = 4096
+
+// Error - ошибка разбора или вычисления с позицией в исходном выражении
+type Error struct {
+	Pos int // позиция символа, начиная с 1
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// Ident - идентификатор, на который ссылается выражение
+type Ident struct {
+	Name string
+	Pos  int
+}
+
+// Program - скомпилированное выражение, безопасное для конкурентного использования
+type Program struct {
+	source string
+	root   node
+	idents []Ident
+}
+
+// Compile разбирает выражение и возвращает готовую к вычислению программу
+func Compile(source string) (*Program, error) {
+	if utf8.RuneCountInString(source) > maxSourceLength {
+		return nil, &Error{Pos: maxSourceLength + 1, Msg: "expression is too long (max " + strconv.Itoa(maxSourceLength) + " characters)"}
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &Error{Pos: 1, Msg: "empty expression"}
+	}
+
+	root, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &Error{Pos: t.pos, Msg: "unexpected " + describe(t) + " after end of expression"}
+	}
+
+	idents := make([]Ident, 0, len(p.idents))
+	for _, id := range p.idents {
+		idents = append(idents, Ident{Name: id.name(), Pos: id.pos})
+	}
+
+	return &Program{source: source, root: root, idents: idents}, nil
+}
+
+// Source возвращает исходный текст выражения
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Idents возвращает все идентификаторы, используемые в выражении
+func (p *Program) Idents() []Ident {
+	return p.idents
+}
+
+// Eval вычисляет выражение в окружении env. Результат выражения должен быть логическим.
+func (p *Program) Eval(env map[string]any) (bool, error) {
+	v, err := eval(p.root, env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, &Error{Pos: p.root.position(), Msg: "expression must evaluate to bool, got " + typeName(v)}
+	}
+
+	return b, nil
+}
@@ -0,0 +1,275 @@
+package expr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testEnv = map[string]any{
+	"event": map[string]any{
+		"kind":    "push",
+		"project": "billing",
+		"ref":     "refs/heads/release/1.2",
+		"branch":  "release/1.2",
+		"files":   []string{"services/billing/api.go", "README.md"},
+		"commits": 3,
+		"draft":   false,
+		"labels":  []any{"bug", "urgent"},
+		"user":    map[string]any{"name": "alice"},
+	},
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{
+			src: `event.kind == "push"`,
+			want: []token{
+				{kind: tokIdent, text: "event", pos: 1},
+				{kind: tokDot, text: ".", pos: 6},
+				{kind: tokIdent, text: "kind", pos: 7},
+				{kind: tokEq, text: "==", pos: 12},
+				{kind: tokString, text: "push", pos: 15},
+				{kind: tokEOF, pos: 21},
+			},
+		},
+		{
+			src: `!a&&b||c != 1.5`,
+			want: []token{
+				{kind: tokNot, text: "!", pos: 1},
+				{kind: tokIdent, text: "a", pos: 2},
+				{kind: tokAnd, text: "&&", pos: 3},
+				{kind: tokIdent, text: "b", pos: 5},
+				{kind: tokOr, text: "||", pos: 6},
+				{kind: tokIdent, text: "c", pos: 8},
+				{kind: tokNeq, text: "!=", pos: 10},
+				{kind: tokNumber, text: "1.5", pos: 13},
+				{kind: tokEOF, pos: 16},
+			},
+		},
+		{
+			src: `not x and y or z in [true, false] matches`,
+			want: []token{
+				{kind: tokNot, text: "not", pos: 1},
+				{kind: tokIdent, text: "x", pos: 5},
+				{kind: tokAnd, text: "and", pos: 7},
+				{kind: tokIdent, text: "y", pos: 11},
+				{kind: tokOr, text: "or", pos: 13},
+				{kind: tokIdent, text: "z", pos: 16},
+				{kind: tokIn, text: "in", pos: 18},
+				{kind: tokLBracket, text: "[", pos: 21},
+				{kind: tokTrue, text: "true", pos: 22},
+				{kind: tokComma, text: ",", pos: 26},
+				{kind: tokFalse, text: "false", pos: 28},
+				{kind: tokRBracket, text: "]", pos: 33},
+				{kind: tokMatches, text: "matches", pos: 35},
+				{kind: tokEOF, pos: 42},
+			},
+		},
+		{
+			// Позиции считаются в символах, а не в байтах
+			src: `'проект' <= "a\"b\n"`,
+			want: []token{
+				{kind: tokString, text: "проект", pos: 1},
+				{kind: tokLte, text: "<=", pos: 10},
+				{kind: tokString, text: "a\"b\n", pos: 13},
+				{kind: tokEOF, pos: 21},
+			},
+		},
+		{
+			src:  "",
+			want: []token{{kind: tokEOF, pos: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := lex(tt.src)
+		if err != nil {
+			t.Errorf("lex(%q): unexpected error: %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lex(%q) =\n%+v\nwant\n%+v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{``, 1, "empty expression"},
+		{`   `, 1, "empty expression"},
+		{`a == "x`, 6, "unterminated string literal"},
+		{`"a\`, 3, "unterminated escape sequence"},
+		{`"a\q"`, 4, `unknown escape sequence \q`},
+		{`a == 'b\x'`, 9, `unknown escape sequence \x`},
+		{`a = b`, 3, "unexpected character '='"},
+		{`a & b`, 3, "unexpected character '&'"},
+		{`a == 1.2.3`, 6, `invalid number "1.2.3"`},
+		{`a ==`, 5, "unexpected end of expression"},
+		{`a b`, 3, `unexpected identifier "b" after end of expression`},
+		{`a == b == c`, 8, "use parentheses to chain comparisons"},
+		{`a. == b`, 4, "expected identifier, got '=='"},
+		{`(a == b`, 8, "expected ')', got end of expression"},
+		{`a in [1, 2`, 11, "expected ',' or ']' in list"},
+		{`a in [1 2]`, 9, "expected ',' or ']' in list, got number 2"},
+		{`a matches b`, 11, "right operand of 'matches' must be a string literal"},
+		{`a matches "("`, 11, "invalid regular expression"},
+		{strings.Repeat("(", maxDepth+1) + "a" + strings.Repeat(")", maxDepth+1), maxDepth + 1, "nested too deeply"},
+		{strings.Repeat("!", maxDepth) + "a", maxDepth, "nested too deeply"},
+		{"a && " + strings.Repeat("!(", maxDepth/2) + "b" + strings.Repeat(")", maxDepth/2), 6 + maxDepth, "nested too deeply"},
+		{strings.Repeat("a", maxSourceLength+1), maxSourceLength + 1, "expression is too long"},
+	}
+
+	for _, tt := range tests {
+		_, err := Compile(tt.src)
+
+		var exprErr *Error
+		if !errors.As(err, &exprErr) {
+			t.Errorf("Compile(%.40q): expected *Error, got %v", tt.src, err)
+			continue
+		}
+		if exprErr.Pos != tt.pos || !strings.Contains(exprErr.Msg, tt.msg) {
+			t.Errorf("Compile(%.40q) error = %q at %d, want %q at %d", tt.src, exprErr.Msg, exprErr.Pos, tt.msg, tt.pos)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`event.kind == "push"`, true},
+		{`event.kind != 'push'`, false},
+		{`event.commits == 3`, true},
+		{`event.commits >= 3 && event.commits < 4`, true},
+		{`event.commits > 3`, false},
+		{`event.commits <= 2.5`, false},
+		{`event.project < "c"`, true},
+		{`event.project >= "billing"`, true},
+		{`event.draft == false`, true},
+
+		// in: список, подстрока, элемент списка окружения
+		{`event.kind in ["push", "tag_push"]`, true},
+		{`event.kind in []`, false},
+		{`"release" in event.branch`, true},
+		{`"hotfix" in event.branch`, false},
+		{`"README.md" in event.files`, true},
+		{`"urgent" in event.labels`, true},
+		{`3 in [1, 2, 3]`, true},
+		{`[1, "a"] == [1, "a"]`, true},
+		{`[1, "a"] == [1, "b"]`, false},
+		{`[1] == [1, 1]`, false},
+		{`1 == "1"`, false},
+
+		// matches: RE2
+		{`event.ref matches "^refs/heads/release/"`, true},
+		{`event.branch matches '^main$'`, false},
+
+		// Логика и приоритеты: ! > сравнения > && > ||
+		{`!event.draft`, true},
+		{`!event.kind == "tag_push"`, true},
+		{`not (event.kind == "push")`, false},
+		{`false || true && false`, false},
+		{`(false || true) && true`, true},
+		{`event.kind == "push" and event.user.name == "alice" or false`, true},
+		{`!!true`, true},
+
+		// Короткая схема: правая часть не вычисляется
+		{`false && event.missing == 1`, false},
+		{`true || event.missing == 1`, true},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.src)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.src, err)
+			continue
+		}
+
+		got, err := p.Eval(testEnv)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{`event.missing == 1`, 1, "unknown field event.missing"},
+		{`event.kind.name == 1`, 1, "event.kind is not an object"},
+		{`event.kind`, 1, "expression must evaluate to bool, got string"},
+		{`"a"`, 1, "expression must evaluate to bool, got string"},
+		{`event.commits && true`, 1, "expected bool, got number"},
+		{`!event.kind`, 2, "expected bool, got string"},
+		{`event.commits matches "3"`, 1, "left operand of 'matches' must be string, got number"},
+		{`1 in "abc"`, 1, "left operand of 'in' must be string when searching in a string"},
+		{`"a" in event.commits`, 8, "right operand of 'in' must be list or string, got number"},
+		{`event.commits < "3"`, 15, "cannot compare number with string"},
+		{`true < false`, 6, "operator '<' is not defined for bool"},
+		{`event.user < 1`, 12, "operator '<' is not defined for object"},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.src)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.src, err)
+			continue
+		}
+
+		_, err = p.Eval(testEnv)
+
+		var exprErr *Error
+		if !errors.As(err, &exprErr) {
+			t.Errorf("Eval(%q): expected *Error, got %v", tt.src, err)
+			continue
+		}
+		if exprErr.Pos != tt.pos || !strings.Contains(exprErr.Msg, tt.msg) {
+			t.Errorf("Eval(%q) error = %q at %d, want %q at %d", tt.src, exprErr.Msg, exprErr.Pos, tt.msg, tt.pos)
+		}
+	}
+}
+
+func TestIdents(t *testing.T) {
+	p, err := Compile(`event.kind == "push" && ("x" in event.user.name || flag)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Ident{
+		{Name: "event.kind", Pos: 1},
+		{Name: "event.user.name", Pos: 33},
+		{Name: "flag", Pos: 52},
+	}
+	if got := p.Idents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Idents() = %+v, want %+v", got, want)
+	}
+	if got := p.Source(); got != `event.kind == "push" && ("x" in event.user.name || flag)` {
+		t.Errorf("Source() = %q", got)
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	err := &Error{Pos: 7, Msg: "unexpected character '='"}
+	if got := err.Error(); got != "position 7: unexpected character '='" {
+		t.Errorf("Error() = %q", got)
+	}
+}
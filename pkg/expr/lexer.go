@@ -0,0 +1,219 @@
+package expr
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokTrue
+	tokFalse
+	tokAnd      // &&
+	tokOr       // ||
+	tokNot      // !
+	tokEq       // ==
+	tokNeq      // !=
+	tokLt       // <
+	tokLte      // <=
+	tokGt       // >
+	tokGte      // >=
+	tokIn       // in
+	tokMatches  // matches
+	tokDot      // .
+	tokComma    // ,
+	tokLParen   // (
+	tokRParen   // )
+	tokLBracket // [
+	tokRBracket // ]
+)
+
+var tokenNames = map[tokenKind]string{
+	tokEOF:      "end of expression",
+	tokIdent:    "identifier",
+	tokString:   "string",
+	tokNumber:   "number",
+	tokTrue:     "true",
+	tokFalse:    "false",
+	tokAnd:      "'&&'",
+	tokOr:       "'||'",
+	tokNot:      "'!'",
+	tokEq:       "'=='",
+	tokNeq:      "'!='",
+	tokLt:       "'<'",
+	tokLte:      "'<='",
+	tokGt:       "'>'",
+	tokGte:      "'>='",
+	tokIn:       "'in'",
+	tokMatches:  "'matches'",
+	tokDot:      "'.'",
+	tokComma:    "','",
+	tokLParen:   "'('",
+	tokRParen:   "')'",
+	tokLBracket: "'['",
+	tokRBracket: "']'",
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+var keywords = map[string]tokenKind{
+	"true":    tokTrue,
+	"false":   tokFalse,
+	"in":      tokIn,
+	"matches": tokMatches,
+	"and":     tokAnd,
+	"or":      tokOr,
+	"not":     tokNot,
+}
+
+type token struct {
+	kind tokenKind
+	text string // значение литерала или имя идентификатора
+	pos  int    // позиция первого символа (с 1, в символах)
+}
+
+// lex разбивает выражение на токены
+func lex(src string) ([]token, error) {
+	var tokens []token
+
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+
+		case r == '"' || r == '\'':
+			text, next, err := lexString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, pos: pos})
+			i = next
+			continue
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), pos: pos})
+			continue
+
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			word := string(runes[start:i])
+			kind, ok := keywords[word]
+			if !ok {
+				kind = tokIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: pos})
+			continue
+		}
+
+		kind, width := lexOperator(runes[i:])
+		if width == 0 {
+			return nil, &Error{Pos: pos, Msg: "unexpected character " + quoteRune(r)}
+		}
+		tokens = append(tokens, token{kind: kind, text: string(runes[i : i+width]), pos: pos})
+		i += width
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(runes) + 1}), nil
+}
+
+func lexOperator(runes []rune) (tokenKind, int) {
+	if len(runes) >= 2 {
+		switch string(runes[:2]) {
+		case "&&":
+			return tokAnd, 2
+		case "||":
+			return tokOr, 2
+		case "==":
+			return tokEq, 2
+		case "!=":
+			return tokNeq, 2
+		case "<=":
+			return tokLte, 2
+		case ">=":
+			return tokGte, 2
+		}
+	}
+
+	switch runes[0] {
+	case '!':
+		return tokNot, 1
+	case '<':
+		return tokLt, 1
+	case '>':
+		return tokGt, 1
+	case '.':
+		return tokDot, 1
+	case ',':
+		return tokComma, 1
+	case '(':
+		return tokLParen, 1
+	case ')':
+		return tokRParen, 1
+	case '[':
+		return tokLBracket, 1
+	case ']':
+		return tokRBracket, 1
+	}
+
+	return tokEOF, 0
+}
+
+// lexString читает строковый литерал в одинарных или двойных кавычках
+func lexString(runes []rune, start int) (string, int, error) {
+	quote := runes[start]
+	var b strings.Builder
+
+	for i := start + 1; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(runes) {
+				return "", 0, &Error{Pos: i + 1, Msg: "unterminated escape sequence"}
+			}
+			i++
+			switch runes[i] {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			case '\\', '"', '\'':
+				b.WriteRune(runes[i])
+			default:
+				return "", 0, &Error{Pos: i + 1, Msg: "unknown escape sequence \\" + string(runes[i])}
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return "", 0, &Error{Pos: start + 1, Msg: "unterminated string literal"}
+}
+
+func quoteRune(r rune) string {
+	if r == utf8.RuneError {
+		return "(invalid UTF-8)"
+	}
+	return "'" + string(r) + "'"
+}
@@ -0,0 +1,297 @@
+package expr
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// node - узел синтаксического дерева выражения
+type node interface {
+	position() int
+}
+
+type literalNode struct {
+	pos   int
+	value any
+}
+
+type listNode struct {
+	pos   int
+	items []node
+}
+
+type identNode struct {
+	pos  int
+	path []string
+}
+
+type unaryNode struct {
+	pos     int
+	operand node
+}
+
+type binaryNode struct {
+	pos   int
+	op    tokenKind
+	left  node
+	right node
+	re    *regexp.Regexp // скомпилированный шаблон для оператора matches
+}
+
+func (n *literalNode) position() int { return n.pos }
+func (n *listNode) position() int    { return n.pos }
+func (n *identNode) position() int   { return n.pos }
+func (n *unaryNode) position() int   { return n.pos }
+func (n *binaryNode) position() int  { return n.pos }
+
+// maxDepth ограничивает вложенность выражения, чтобы исключить переполнение стека
+const maxDepth = 64
+
+type parser struct {
+	tokens []token
+	cur    int
+	depth  int
+	idents []*identNode
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.cur]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.cur]
+	if t.kind != tokEOF {
+		p.cur++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, &Error{Pos: t.pos, Msg: "expected " + kind.String() + ", got " + describe(t)}
+	}
+	return t, nil
+}
+
+func (p *parser) parseExpression() (node, error) {
+	defer p.leave()
+	if err := p.enter(p.peek().pos); err != nil {
+		return nil, err
+	}
+
+	return p.parseOr()
+}
+
+// enter учитывает уровень вложенности (скобки, списки, отрицания); после разбора уровня вызывается leave
+func (p *parser) enter(pos int) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return &Error{Pos: pos, Msg: "expression is nested too deeply"}
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokOr {
+		op := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: op.pos, op: tokOr, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokAnd {
+		op := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: op.pos, op: tokAnd, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peek()
+	switch op.kind {
+	case tokEq, tokNeq, tokLt, tokLte, tokGt, tokGte, tokIn, tokMatches:
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	bin := &binaryNode{pos: op.pos, op: op.kind, left: left, right: right}
+
+	// Шаблон регулярного выражения компилируется один раз при разборе
+	if op.kind == tokMatches {
+		lit, ok := right.(*literalNode)
+		pattern, isString := lit.valueString()
+		if !ok || !isString {
+			return nil, &Error{Pos: right.position(), Msg: "right operand of 'matches' must be a string literal"}
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, &Error{Pos: right.position(), Msg: "invalid regular expression: " + err.Error()}
+		}
+		bin.re = re
+	}
+
+	// Цепочки сравнений (a == b == c) неоднозначны - запрещаем их явно
+	switch p.peek().kind {
+	case tokEq, tokNeq, tokLt, tokLte, tokGt, tokGte, tokIn, tokMatches:
+		t := p.peek()
+		return nil, &Error{Pos: t.pos, Msg: "unexpected " + describe(t) + ", use parentheses to chain comparisons"}
+	}
+
+	return bin, nil
+}
+
+// parseNot разбирает отрицание. Оно применяется ко всему сравнению,
+// поэтому !event.kind == "push" означает !(event.kind == "push").
+func (p *parser) parseNot() (node, error) {
+	if p.peek().kind == tokNot {
+		op := p.next()
+		// Цепочка !!!… разбирается рекурсивно и тоже ограничена maxDepth
+		defer p.leave()
+		if err := p.enter(op.pos); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: op.pos, operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokString:
+		return &literalNode{pos: t.pos, value: t.text}, nil
+
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &Error{Pos: t.pos, Msg: "invalid number " + strconv.Quote(t.text)}
+		}
+		return &literalNode{pos: t.pos, value: f}, nil
+
+	case tokTrue, tokFalse:
+		return &literalNode{pos: t.pos, value: t.kind == tokTrue}, nil
+
+	case tokIdent:
+		ident := &identNode{pos: t.pos, path: []string{t.text}}
+		for p.peek().kind == tokDot {
+			p.next()
+			field, err := p.expect(tokIdent)
+			if err != nil {
+				return nil, err
+			}
+			ident.path = append(ident.path, field.text)
+		}
+		p.idents = append(p.idents, ident)
+		return ident, nil
+
+	case tokLBracket:
+		return p.parseList(t)
+
+	case tokLParen:
+		inner, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+
+	return nil, &Error{Pos: t.pos, Msg: "unexpected " + describe(t)}
+}
+
+func (p *parser) parseList(open token) (node, error) {
+	list := &listNode{pos: open.pos}
+
+	if p.peek().kind == tokRBracket {
+		p.next()
+		return list, nil
+	}
+
+	for {
+		item, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		list.items = append(list.items, item)
+
+		t := p.next()
+		switch t.kind {
+		case tokComma:
+			continue
+		case tokRBracket:
+			return list, nil
+		}
+		return nil, &Error{Pos: t.pos, Msg: "expected ',' or ']' in list, got " + describe(t)}
+	}
+}
+
+func (n *literalNode) valueString() (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	s, ok := n.value.(string)
+	return s, ok
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokIdent:
+		return "identifier " + strconv.Quote(t.text)
+	case tokString:
+		return "string " + strconv.Quote(t.text)
+	case tokNumber:
+		return "number " + t.text
+	}
+	return t.kind.String()
+}
+
+// name возвращает имя идентификатора в виде "a.b.c"
+func (n *identNode) name() string {
+	return strings.Join(n.path, ".")
+}
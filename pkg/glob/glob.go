@@ -0,0 +1,66 @@
+package glob
+
+import (
+	"path"
+	"strings"
+)
+
+// Match проверяет, соответствует ли путь файла шаблону.
+// Поддерживается синтаксис path.Match для отдельных сегментов пути,
+// а также сегмент "**", который совпадает с любым количеством директорий
+// (включая ноль): "services/billing/**", "**/*.go", "docs/**/README.md".
+func Match(pattern, name string) bool {
+	pattern = strings.Trim(pattern, "/")
+	name = strings.Trim(name, "/")
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// Valid проверяет синтаксис шаблона без сопоставления с путём
+func Valid(pattern string) bool {
+	for _, segment := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Схлопываем подряд идущие "**"
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+
+			// Пробуем сопоставить остаток шаблона с каждым суффиксом пути
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
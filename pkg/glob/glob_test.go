@@ -0,0 +1,74 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		// Без "**" - как path.Match по сегментам
+		{"README.md", "README.md", true},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/README.md", false},
+		{"docs/*.md", "docs/README.md", true},
+		{"docs/*.md", "docs/api/README.md", false},
+		{"cmd/?erver/main.go", "cmd/server/main.go", true},
+		{"[a-c].go", "b.go", true},
+		{"[a-c].go", "d.go", false},
+		{"docs", "docs/README.md", false},
+
+		// "**" - любое количество директорий, включая ноль
+		{"**", "main.go", true},
+		{"**", "a/b/c.go", true},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "internal/usecase/notifier.go", true},
+		{"**/*.go", "internal/usecase/notifier.yaml", false},
+		{"services/billing/**", "services/billing/api/handler.go", true},
+		{"services/billing/**", "services/billing", true},
+		{"services/billing/**", "services/payments/api.go", false},
+		{"docs/**/README.md", "docs/README.md", true},
+		{"docs/**/README.md", "docs/a/b/README.md", true},
+		{"docs/**/README.md", "docs/a/b/index.md", false},
+		{"a/**/b/**/c", "a/x/b/y/z/c", true},
+		{"a/**/b/**/c", "a/x/y/c", false},
+		{"a/**/**/c", "a/c", true},
+		{"a/**/b", "a", false},
+
+		// Ведущие и завершающие "/" не учитываются
+		{"/docs/*.md", "docs/README.md", true},
+		{"docs/", "/docs/", true},
+
+		// Некорректный шаблон ни с чем не совпадает
+		{"[", "[", false},
+		{"**/[", "a/[", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"**", true},
+		{"**/*.go", true},
+		{"services/billing/**", true},
+		{"[a-z]*.go", true},
+		{"[", false},
+		{"docs/[a-/x", false},
+		{"**/\\", false},
+	}
+
+	for _, tt := range tests {
+		if got := Valid(tt.pattern); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package metrics - минимальный реестр метрик в текстовом формате Prometheus без внешних зависимостей
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type collector interface {
+	write(b *strings.Builder)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]collector{}
+)
+
+func register(name string, c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[name]; exists {
+		panic("metrics: duplicate metric " + name)
+	}
+	registry[name] = c
+}
+
+// Counter - монотонно растущий счётчик
+type Counter struct {
+	name, help string
+	value      atomic.Int64
+}
+
+// NewCounter создаёт и регистрирует счётчик
+func NewCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	register(name, c)
+	return c
+}
+
+func (c *Counter) Inc()         { c.value.Add(1) }
+func (c *Counter) Add(n int64)  { c.value.Add(n) }
+func (c *Counter) Value() int64 { return c.value.Load() }
+
+func (c *Counter) write(b *strings.Builder) {
+	writeHeader(b, c.name, c.help, "counter")
+	fmt.Fprintf(b, "%s %d\n", c.name, c.Value())
+}
+
+// CounterVec - счётчик с одной меткой, например result="success"
+type CounterVec struct {
+	name, help, label string
+	mu                sync.Mutex
+	values            map[string]*atomic.Int64
+}
+
+// NewCounterVec создаёт и регистрирует счётчик с меткой label
+func NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{name: name, help: help, label: label, values: map[string]*atomic.Int64{}}
+	register(name, c)
+	return c
+}
+
+// Inc увеличивает счётчик для значения метки
+func (c *CounterVec) Inc(labelValue string) {
+	c.Add(labelValue, 1)
+}
+
+// Add увеличивает счётчик для значения метки на n
+func (c *CounterVec) Add(labelValue string, n int64) {
+	c.mu.Lock()
+	v, ok := c.values[labelValue]
+	if !ok {
+		v = &atomic.Int64{}
+		c.values[labelValue] = v
+	}
+	c.mu.Unlock()
+
+	v.Add(n)
+}
+
+// Value возвращает значение счётчика для значения метки
+func (c *CounterVec) Value(labelValue string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.values[labelValue]; ok {
+		return v.Load()
+	}
+	return 0
+}
+
+func (c *CounterVec) write(b *strings.Builder) {
+	writeHeader(b, c.name, c.help, "counter")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	labels := make([]string, 0, len(c.values))
+	for l := range c.values {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+
+	for _, l := range labels {
+		fmt.Fprintf(b, "%s{%s=%s} %d\n", c.name, c.label, strconv.Quote(l), c.values[l].Load())
+	}
+}
+
+// GaugeFunc - показатель, значение которого вычисляется при каждом сборе метрик
+type GaugeFunc struct {
+	name, help string
+	fn         func() float64
+}
+
+// NewGaugeFunc создаёт и регистрирует показатель
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, fn: fn}
+	register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(b *strings.Builder) {
+	writeHeader(b, g.name, g.help, "gauge")
+	fmt.Fprintf(b, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+func writeHeader(b *strings.Builder, name, help, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, kind)
+}
+
+func formatFloat(v float64) string {
+	if math.IsNaN(v) {
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler отдаёт все зарегистрированные метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registryMu.Lock()
+		names := make([]string, 0, len(registry))
+		for name := range registry {
+			names = append(names, name)
+		}
+		collectors := make([]collector, 0, len(names))
+		sort.Strings(names)
+		for _, name := range names {
+			collectors = append(collectors, registry[name])
+		}
+		registryMu.Unlock()
+
+		var b strings.Builder
+		for _, c := range collectors {
+			c.write(&b)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(b.String()))
+	})
+}
@@ -0,0 +1,149 @@
+// Package tlscert загружает TLS-сертификат и перечитывает его при изменении файлов без перезапуска сервера
+package tlscert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/sensetion/tgGitlabBot/pkg/metrics"
+)
+
+// reloadDebounce - пауза после последнего изменения файлов перед перечитыванием:
+// сертификат и ключ обычно обновляются не одновременно
+const reloadDebounce = 300 * time.Millisecond
+
+var certificateReloads = metrics.NewCounterVec(
+	"tgbot_tls_certificate_reloads_total",
+	"TLS certificate reloads by result",
+	"result",
+)
+
+// Reloader хранит текущий сертификат и отдаёт его через GetCertificate.
+// При ошибке перечитывания продолжает использоваться предыдущий сертификат.
+type Reloader struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+}
+
+// NewReloader загружает сертификат и ключ; ошибка загрузки возвращается сразу
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate подходит для tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Reload перечитывает сертификат и ключ
+func (r *Reloader) Reload() {
+	if err := r.load(); err != nil {
+		certificateReloads.Inc("failure")
+		log.Printf("❌ Не удалось перезагрузить TLS-сертификат, используется предыдущий: %v", err)
+		return
+	}
+
+	certificateReloads.Inc("success")
+	log.Printf("🔐 TLS-сертификат %s перезагружен, действителен до %s", r.certFile, r.notAfter())
+}
+
+// Run следит за файлами сертификата и ключа до отмены контекста
+func (r *Reloader) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Следим за директориями: файлы заменяются атомарно (rename) или через симлинк ..data в Kubernetes
+	names := map[string]struct{}{
+		filepath.Base(r.certFile): {},
+		filepath.Base(r.keyFile):  {},
+		"..data":                  {},
+	}
+	for _, dir := range uniqueDirs(r.certFile, r.keyFile) {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if _, watched := names[filepath.Base(event.Name)]; watched {
+				debounce.Reset(reloadDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("⚠️ Ошибка отслеживания TLS-сертификата: %v", err)
+
+		case <-debounce.C:
+			r.Reload()
+		}
+	}
+}
+
+func (r *Reloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate %s: %w", r.certFile, err)
+	}
+
+	r.cert.Store(&cert)
+	return nil
+}
+
+// notAfter возвращает срок действия текущего сертификата для логов
+func (r *Reloader) notAfter() string {
+	cert := r.cert.Load()
+	if cert == nil || len(cert.Certificate) == 0 {
+		return "unknown"
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return "unknown"
+	}
+	return leaf.NotAfter.Format(time.RFC3339)
+}
+
+func uniqueDirs(paths ...string) []string {
+	var dirs []string
+	seen := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		seen[dir] = struct{}{}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}